@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"hash/maphash"
+	"sort"
+	"time"
+)
+
+// TinyLFU admission settings.
+const (
+	sketchRows     = 4    // number of counters for each key, each in a different row.
+	sketchMinWidth = 1024 // minimum number of counters in each row.
+	sketchMaxCount = 15   // counters stop at this value, like the 4-bit counters in TinyLFU.
+	sketchSamples  = 10   // counters are halved after this many increments per counter in a row.
+)
+
+// sketch is a count-min sketch that estimates how often each key is retrieved.
+// The counts are halved periodically, so keys that were popular long ago lose their advantage.
+type sketch struct {
+	seed    maphash.Seed
+	rows    [sketchRows][]uint8
+	added   int // increments since the counts were last halved.
+	entries int // the number of keys the sketch is sized for.
+}
+
+func newSketch(entries int) *sketch {
+	width := sketchMinWidth
+	for width < entries {
+		width *= 2
+	}
+
+	sketch := &sketch{seed: maphash.MakeSeed(), entries: width}
+	for row := range sketch.rows {
+		sketch.rows[row] = make([]uint8, width)
+	}
+
+	return sketch
+}
+
+// indexes returns the counter index for a key in each row.
+func (s *sketch) indexes(key string) [sketchRows]int {
+	hash := maphash.String(s.seed, key)
+	first, second := uint32(hash), uint32(hash>>32) //nolint:gosec // splitting the hash in two.
+	mask := uint32(len(s.rows[0]) - 1)
+
+	var idx [sketchRows]int
+	for row := range idx {
+		idx[row] = int((first + uint32(row)*second) & mask)
+	}
+
+	return idx
+}
+
+// add counts one retrieval of a key.
+func (s *sketch) add(key string) {
+	for row, idx := range s.indexes(key) {
+		if s.rows[row][idx] < sketchMaxCount {
+			s.rows[row][idx]++
+		}
+	}
+
+	if s.added++; s.added >= sketchSamples*len(s.rows[0]) {
+		s.age()
+	}
+}
+
+// age halves every counter.
+func (s *sketch) age() {
+	for row := range s.rows {
+		for idx := range s.rows[row] {
+			s.rows[row][idx] /= 2
+		}
+	}
+
+	s.added /= 2
+}
+
+// estimate returns how many times a key was retrieved recently; it may be more, never less.
+func (s *sketch) estimate(key string) uint8 {
+	count := uint8(sketchMaxCount)
+	for row, idx := range s.indexes(key) {
+		count = min(count, s.rows[row][idx])
+	}
+
+	return count
+}
+
+// access tells the EvictionPolicy about a get request for an existing key, and counts
+// every get request when TinyLFU is enabled. This runs inside the processor.
+func (c *Cache) access(key string, hit bool) {
+	if hit && c.conf.Eviction != nil {
+		c.conf.Eviction.OnGet(key)
+	}
+
+	if c.sketch == nil {
+		return
+	}
+
+	if c.cache.Len() > c.sketch.entries { // the counts are lost, but the cache outgrew them.
+		c.sketch = newSketch(c.cache.Len() * 2) //nolint:mnd // room to grow.
+	}
+
+	c.sketch.add(key)
+}
+
+// evictOrder returns the first n items to evict, in the order they should be evicted.
+// Without TinyLFU, this is the least recently used items. With TinyLFU, items that were
+// never retrieved are candidates: each candidate is compared to the least recently used
+// item that was retrieved, and the one retrieved less often is evicted; the other stays.
+// This runs inside the processor.
+func (c *Cache) evictOrder(n int, now time.Time) []KeyStat {
+	if c.sketch == nil {
+		lru := c.rank(n, "", now, c.evictFirst, pinned).stats
+		sort.Slice(lru, func(i, j int) bool { return c.evictFirst(&lru[i], &lru[j]) })
+
+		return lru
+	}
+
+	lru := c.rank(c.cache.Len(), "", now, c.evictFirst, pinned).stats
+	sort.Slice(lru, func(i, j int) bool { return c.evictFirst(&lru[i], &lru[j]) })
+
+	var candidates, residents, admitted []KeyStat
+
+	for _, stat := range lru {
+		if stat.Hits == 0 {
+			candidates = append(candidates, stat)
+		} else {
+			residents = append(residents, stat)
+		}
+	}
+
+	order := make([]KeyStat, 0, len(lru))
+
+	for len(candidates) > 0 && len(residents) > 0 && len(order) < n {
+		if c.sketch.estimate(candidates[0].Key) > c.sketch.estimate(residents[0].Key) {
+			order = append(order, residents[0])
+			admitted = append(admitted, candidates[0])
+			residents = residents[1:]
+		} else {
+			order = append(order, candidates[0])
+		}
+
+		candidates = candidates[1:]
+	}
+
+	order = append(append(append(order, residents...), candidates...), admitted...)
+
+	return order[:min(n, len(order))]
+}
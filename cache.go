@@ -3,6 +3,14 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -12,9 +20,11 @@ import (
 type Config struct {
 	// PruneInterval enables the pruner routine and controls
 	// how often it checks every cache key and deletes those eligible.
-	// This must be enabled to use Expire time on Items.
+	// Without it, expired items are only removed when they're retrieved.
 	// If you don't want other prunes to happen,
 	// set really long durations for PruneAfter and/or MaxUnused.
+	// Set both to Forever, without a Refresher, and the pruner only removes
+	// expired items, without checking every key.
 	// @recommend 3 minutes - 5 minutes
 	PruneInterval time.Duration
 	// PruneAfter causes the pruner routine to prune keys marked prunable
@@ -27,6 +37,61 @@ type Config struct {
 	// Pass cache.Forever to avoid expiring non-prunable items.
 	// @default 25 hours
 	MaxUnused time.Duration
+	// PruneBatch limits how many keys the pruner routine checks each PruneInterval.
+	// Each run continues with the keys the last run did not check, so every key is still
+	// checked, but a large cache is pruned over several intervals. This avoids long pauses
+	// for other requests while a big cache is pruned. Prune() always checks every key.
+	// Expired items are found without checking keys, so they are always removed on time.
+	// @default 0, check every key each run.
+	PruneBatch int
+	// PruneJitter randomizes every PruneInterval by up to this fraction in either direction.
+	// 0.1 makes each interval between 90% and 110% of PruneInterval. Use it to keep many
+	// caches in one process from pruning at the same moment. This can be set from 0 to 1.
+	PruneJitter float64
+	// MemoryPressure enables evicting the least recently used items when the Go heap grows
+	// past this fraction of the memory limit set with GOMEMLIMIT or debug.SetMemoryLimit().
+	// Each time the pruner runs with the heap over this fraction, 10% of the items are
+	// evicted, up to half of them when the heap reaches the limit. Nothing is evicted
+	// when the heap is under this fraction, or when there is no memory limit.
+	// This requires PruneInterval to be set. It can be set from 0 (disabled) to 0.99.
+	MemoryPressure float64
+	// MemoryFraction limits the estimated size of the cache to this fraction of the memory
+	// limit set with GOMEMLIMIT or debug.SetMemoryLimit(). Each time the pruner runs with the
+	// estimate over the limit, the least recently used items are evicted to get under it.
+	// The estimate counts keys, strings and byte slices by length, and other data by the size
+	// of its type. Nothing is evicted when there is no memory limit.
+	// This requires PruneInterval to be set. It can be set from 0 (disabled) to 1.
+	MemoryFraction float64
+	// MaxCost limits the total Options.Cost of the items in the cache. Each time the pruner
+	// runs with the total over MaxCost, the least recently used items are evicted until it's
+	// not. Items saved without a Cost cost 1, so MaxCost alone limits the number of items.
+	// This requires PruneInterval to be set. @default 0, no limit.
+	MaxCost int64
+	// TinyLFU enables an admission filter for items evicted by MaxCost, MemoryFraction and
+	// MemoryPressure. The cache estimates how often each key is retrieved (including misses),
+	// and an item that was never retrieved is only kept over the least recently used item if
+	// its key was requested more often. This keeps a scan of one-time keys from evicting
+	// popular items. This can not be changed with Reconfigure.
+	TinyLFU bool
+	// Overflow stores the items evicted by MaxCost, MemoryFraction and MemoryPressure,
+	// and moves them back into the cache when they're requested. See Overflow.
+	Overflow Overflow
+	// Eviction chooses the items evicted by MaxCost, MemoryFraction and MemoryPressure.
+	// Use NewLRU, NewLFU, NewFIFO or your own EvictionPolicy. This may not be used with
+	// TinyLFU, and can not be changed with Reconfigure.
+	// @default nil, evict the least recently used items by Priority.
+	Eviction EvictionPolicy
+	// EvictionSamples makes the default eviction check this many random items for each item
+	// it evicts, and evict the least recently used of them, like Redis. It's less accurate
+	// than comparing every item, but much faster for very large caches. 5 is a good start.
+	// This may not be used with TinyLFU or Eviction.
+	// @default 0, compare every item.
+	EvictionSamples int
+	// DefaultOptions are used for items saved with zero Options, so every save site does not
+	// need the same Options. Set TTL in them to expire items a while after they're saved.
+	// This applies to every save, including Increment and Append creating a key. Pass any
+	// non-zero Options to avoid the defaults. This can not be changed with Reconfigure.
+	DefaultOptions Options
 	// RequestAccuracy can be set between 100 milliseconds and 1 minute.
 	// This sets the ticker interval that updates our time.Now() variable.
 	// Generally, the default of 1 second should be fine for most apps.
@@ -34,17 +99,145 @@ type Config struct {
 	// this to a few seconds quite safely and the cache will use fewer cpu cycles.
 	// @default 1 second
 	RequestAccuracy time.Duration
+	// RequestTimeout limits how long a request waits for the processor. A request that
+	// times out is logged, and returns like the key does not exist: Get returns nil and
+	// Save returns false. Use the Context methods, like GetContext, to get an error instead.
+	// A request the processor already received still finishes after it times out.
+	// Requests that run a function in the processor and report its result, like Increment,
+	// Append, Rename, Txn, Flush and TTL, always wait for the processor, because a timed
+	// out request would return a result the processor did not produce.
+	// @default 0, requests wait as long as it takes.
+	RequestTimeout time.Duration
+	// DeepCopy makes the cache call Clone() on data that implements Cloner every time it
+	// returns a copy of an item: from Get, List, Compute, Txn, watchers and the Refresher.
+	// Otherwise, copies share the cached data, and changes to data that pointers, maps
+	// or slices refer to change the cached data. GetData never copies or clones data.
+	DeepCopy bool
+	// QueueSize buffers the request channel, so callers can queue this many requests
+	// without waiting for the processor. This smooths bursts of requests, but each
+	// request allocates its own response channel when this is more than 0.
+	// @default 0, callers wait for the processor to receive each request.
+	QueueSize int
+	// InitialCapacity pre-sizes the cache's map for this many items, so loading
+	// many items after the cache starts does not repeatedly grow the map.
+	// This is only a hint; the cache may hold more or fewer items.
+	InitialCapacity int
+	// Store holds the items. See Store. @default a MapStore sized with InitialCapacity.
+	Store Store
+	// Refresher enables refresh-ahead for items saved with a RefreshAfter option.
+	// Each time the pruner runs, items saved longer ago than their RefreshAfter duration
+	// that have also been retrieved within that duration are passed to this function
+	// in a go routine, and the item is updated with the returned data.
+	// This requires PruneInterval to be set.
+	Refresher Refresher
+	// Loader enables read-through: Get, GetData and Load call it for keys that are not
+	// in the cache, and save the result. See Loader.
+	Loader Loader
+	// Writer enables write-through: it's called with every item saved or updated in the
+	// cache, so the cache can keep durable storage up to date. See Writer. By default the
+	// Writer is called inside the processor, so every request waits for each write, and a
+	// Save returns after its write finished.
+	Writer Writer
+	// WriteAsync calls the Writer in its own go routine, in the order items were saved.
+	// Saves return before the write, and the processor only waits when 1024 writes are
+	// queued. Queued writes finish before Stop returns.
+	WriteAsync bool
+	// MissFilter enables a Bloom filter in front of the Loader, so lookups for keys the
+	// Loader recently did not find return nil without a request to the processor or the
+	// Loader. It requires Loader. See MissFilterConfig.
+	MissFilter *MissFilterConfig
+	// Indexes are secondary indexes, by name. Each function returns the value an item is
+	// indexed by, like a user ID stored in a session, or "" to leave the item out of the
+	// index. Keys include the namespace prefix. The functions are called inside the
+	// processor every time an item is saved or updated, so they must be fast, must not
+	// change the item, and must not call the cache. Find items with GetByIndex.
+	// This can not be changed with Reconfigure.
+	Indexes map[string]func(key string, item *Item) string
+	// Name registers the cache in a package-level registry, so it can be retrieved with
+	// cache.Get(name), and publishes its stats to expvar under ExpvarName.
+	// A new cache with the same name replaces the old one in the registry.
+	Name string
+	// Statsd enables sending stats to a statsd or DogStatsD server at an interval.
+	Statsd *StatsdConfig
+	// Journal enables a write-ahead journal, so items are restored when the program restarts.
+	Journal *JournalConfig
+	// SnapshotPath enables snapshots: the cache is written to this file, like Export,
+	// every SnapshotInterval and when the cache stops, and loaded from it when the cache
+	// first starts. Snapshots are written to a temporary file that replaces SnapshotPath,
+	// so a crash during a write leaves the previous snapshot. Items saved since the last
+	// snapshot are lost in a crash; use a Journal to keep them.
+	SnapshotPath string
+	// SnapshotInterval controls how often a snapshot is written. Items are copied inside
+	// the processor, and written in a go routine. @default 0, only when the cache stops.
+	SnapshotInterval time.Duration
+	// SnapshotOptions chooses the codec and compression for snapshots. @default GobCodec
+	SnapshotOptions ExportOptions
+	// Logger receives log messages when the cache starts and stops, prune summaries,
+	// and panics recovered in the processor. Prune summaries are logged at debug level,
+	// or at info level when a prune removes a quarter or more of the cache.
+	// Messages include a cache attribute when Name is set. The cache does not log if this is nil.
+	Logger *slog.Logger
+	// StatsInterval and OnStats make the processor pass a stats snapshot to OnStats
+	// at an interval. OnStats is called in a go routine, so it may call cache methods.
+	// Both must be set to enable this feature.
+	StatsInterval time.Duration
+	OnStats       func(Stats)
+	// TrackLatency records how long each request waits for the processor, and how long
+	// the processor takes to handle it. Percentiles are in Stats.Wait and Stats.Work.
+	// This adds two time.Now() calls to every request, so it's disabled by default.
+	// Latency is always measured with the time package, not the Clock.
+	TrackLatency bool
+	// Clock provides the time and tickers to the cache. Set this to a fake clock to
+	// control time in tests. The cachetest package has one.
+	// @default the time package.
+	Clock Clock
 }
 
 // Cache provides methods to get, save and delete a key (with data) from cache.
 type Cache struct {
-	cache map[string]*Item
-	req   chan *req
-	res   chan *Item
-	run   bool
-	conf  *Config
-	stats Stats
-	mu    sync.Mutex // locks 'run' on Start() and Stop().
+	*core
+	ns string // key prefix for a namespace, empty for the parent cache.
+}
+
+// core is the cache data and processor shared by a cache and all of its namespaces.
+type core struct {
+	cache    Store
+	req      chan *req
+	res      chan *Item
+	run      bool
+	conf     *Config
+	stats    Stats
+	nsStats  map[string]*Stats     // stats for each namespace, keyed by prefix.
+	deltas   map[string]Stats      // stats at the last StatsDelta() call, keyed by prefix.
+	subs     []*subscriber         // event subscribers.
+	watchers map[string][]*watcher // item watchers, keyed by watched key.
+	// refreshed receives Refresher results. It's never closed.
+	refreshed chan *req
+	// indexes has the keys in each of Config.Indexes, by index name and value.
+	indexes map[string]map[string]map[string]struct{}
+	// indexed has the index values of each indexed key, by index name.
+	indexed map[string]map[string]string
+	// stopped is closed when the processor stops, so go routines do not block on it.
+	stopped    chan struct{}
+	quit       chan struct{}           // closed to tell the processor to stop.
+	statsd     net.Conn                // statsd connection, nil if statsd is not configured.
+	statsdSent Stats                   // stats at the last statsd send.
+	pruneNext  []string                // keys left to check in this PruneBatch cycle.
+	expiry     expiryHeap              // items with an Expire time, soonest first.
+	ticks      *tickers                // the processor's tickers, changed by Reconfigure.
+	wait       histogram               // time requests waited for the processor, if TrackLatency is true.
+	work       histogram               // time the processor spent on requests, if TrackLatency is true.
+	version    int64                   // the last Version given to a saved or updated item.
+	sketch     *sketch                 // counts key retrievals for the TinyLFU admission filter, if enabled.
+	journal    *journal                // the open write-ahead journal, nil if it's not configured.
+	snapshots  sync.Mutex              // locked while a snapshot is written.
+	started    bool                    // the cache started before, so the snapshot and journal are not loaded again.
+	spilled    map[string]struct{}     // keys stored in the Overflow.
+	loads      map[string]*call[*Item] // running Loader calls, by key.
+	loadMu     sync.Mutex              // locks loads.
+	writes     chan write              // queued writes for the Writer, if WriteAsync is true.
+	missFilter *missFilter             // keys the Loader did not find, if MissFilter is set.
+	mu         sync.Mutex              // locks 'run' on Start() and Stop().
 }
 
 // Item is what's returned from a cache Get.
@@ -52,24 +245,66 @@ type Cache struct {
 //   - Time is when the item was saved (or updated) in cache.
 //   - Last is the time when the last cache get for this item occurred.
 //   - Hits is the number of cache gets for this key.
+//   - Negative is true if the item was saved with SaveNegative(); Data is the error.
+//   - Expires is the Expire time the item was saved with; zero if it does not expire.
+//   - Prunable is true if the item was saved with Prune or PruneAfter, so it's pruned after PruneAfter.
+//   - Pinned is true if the item was saved with Pin, or pinned with cache.Pin().
+//   - Meta is a copy of the Meta the item was saved with.
+//   - Version changes every time the item is saved or its data is updated. See SaveIfVersion.
 type Item struct {
-	Data any       `json:"data"`
-	Time time.Time `json:"created"`
-	Last time.Time `json:"lastAccess"`
-	Hits int64     `json:"hits"`
-	opts *Options
+	Data     any               `json:"data"`
+	Time     time.Time         `json:"created"`
+	Last     time.Time         `json:"lastAccess"`
+	Hits     int64             `json:"hits"`
+	Negative bool              `json:"negative,omitempty"`
+	Expires  time.Time         `json:"expires"`
+	Prunable bool              `json:"prunable,omitempty"`
+	Pinned   bool              `json:"pinned,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Version  int64             `json:"version"`
+	opts     *Options
+	// refreshing is true while the Refresher is running for this item.
+	refreshing bool
 }
 
 // Options are optional, and may be provided when saving a cached item.
 type Options struct {
 	// Setting Prune true will allow the pruning routine to prune this item.
 	// Items are pruned when they have not been retrieved in the PruneAfter duration.
-	Prune bool
-	// You may set a specific eviction time for an item. This only works if the
-	// pruner is running. The item will be removed from cache after this date/time.
+	Prune bool `json:"prune,omitempty"`
+	// You may set a specific eviction time for an item. The item will be removed from
+	// cache after this date/time: by the pruner if it's running, or when it's retrieved.
+	// Get never returns an expired item, but Keys, List and TTL do until it's removed.
 	// This works independently from setting Prune to true, and follows different logic.
 	// Not setting this, or setting it to zero time will never expire the item.
-	Expire time.Time
+	Expire time.Time `json:"expire"`
+	// TTL sets Expire to this long after the item is saved, if Expire is not set.
+	TTL time.Duration `json:"ttl,omitempty"`
+	// RefreshAfter causes the Config.Refresher to refresh this item after it was saved
+	// (or last refreshed) this long ago, as long as it's still being retrieved.
+	// This only works if the pruner is running.
+	RefreshAfter time.Duration `json:"refreshAfter,omitempty"`
+	// PruneAfter overrides Config.PruneAfter for this item, and makes it prunable like Prune.
+	// Like Config.PruneAfter, it has no effect if it's longer than MaxUnused.
+	// The pruner does not check it when Config.PruneAfter and MaxUnused are both Forever.
+	PruneAfter time.Duration `json:"pruneAfter,omitempty"`
+	// MaxUnused overrides Config.MaxUnused for this item. Use it to keep reference data
+	// that's rarely retrieved, or to prune transient items sooner than the rest of the cache.
+	// The pruner does not check it when Config.PruneAfter and MaxUnused are both Forever.
+	MaxUnused time.Duration `json:"maxUnused,omitempty"`
+	// Pin keeps the item until it's deleted, it expires, or it's unpinned with cache.Pin().
+	// Pinned items are never pruned for being unused, and never evicted for memory.
+	Pin bool `json:"pin,omitempty"`
+	// Priority orders items evicted for memory that were last retrieved at the same time:
+	// lower priority items are evicted first. Give expensive-to-rebuild items a higher priority.
+	// Items are retrieved at times as accurate as Config.RequestAccuracy, so many share a time.
+	Priority int `json:"priority,omitempty"`
+	// Cost is the weight of the item, like its size in bytes, counted against Config.MaxCost.
+	// @default 1
+	Cost int64 `json:"cost,omitempty"`
+	// Meta is stored with the item and returned in Item.Meta, like an origin or a data version.
+	// Item copies get a copy of the map, but the saved map is not copied: do not change it after saving.
+	Meta map[string]string `json:"meta,omitempty"`
 }
 
 // Defaults.
@@ -79,21 +314,64 @@ const (
 	defaultPruneDur  = 18 * time.Minute       // 18m is probably not what you want. (set PruneAfter if 0)
 	defaultAccuracy  = time.Second            // 1-5s is fine for most things.
 	minimumAccuracy  = 100 * time.Millisecond // Minimum is 1/10th of a second.
+	maximumPressure  = 0.99                   // MemoryPressure must leave room to measure pressure.
 	maximumAccuracy  = time.Hour              // Good for slow-use cache.
 )
 
 const (
+	// NamespaceSeparator is placed between a namespace name and the keys inside of it.
+	NamespaceSeparator = ":"
 	// Forever represents the maximum Go Duration.
 	// You may pass this value to Config.MaxUnused to avoid expiring non-prunable items.
 	Forever time.Duration = 1<<63 - 1
 )
 
+// Errors returned by this package.
+var (
+	// ErrNotInteger is returned by Increment when the cached data is not an integer.
+	ErrNotInteger = errors.New("cached data is not an integer")
+	// ErrNotAppendable is returned by Append when the cached data or suffix is not a string or []byte.
+	ErrNotAppendable = errors.New("data is not a string or byte slice")
+	// ErrKeyNotFound is returned when an operation requires a key that does not exist.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrKeyExists is returned when an operation would overwrite a key that already exists.
+	ErrKeyExists = errors.New("key already exists")
+	// ErrInvalidConfig is wrapped by the errors returned from NewE and Reconfigure.
+	ErrInvalidConfig = errors.New("invalid cache config")
+	// ErrRequestTimeout is returned by the Context methods when Config.RequestTimeout passes.
+	ErrRequestTimeout = errors.New("cache request timed out")
+	// ErrStopped is returned when a cache must be running, but it's stopped.
+	ErrStopped = errors.New("cache is stopped")
+	// ErrMemoizePanic is returned to Memoize callers waiting for a function call that panicked.
+	ErrMemoizePanic = errors.New("memoized function panicked")
+	// ErrLoaderPanic is returned to Load callers waiting for a Loader call that panicked.
+	ErrLoaderPanic = errors.New("cache loader panicked")
+	// ErrTxnPanic is returned by Txn when the transaction function panics.
+	ErrTxnPanic = errors.New("cache transaction panicked")
+	// ErrFrameTooLarge is returned by Client.Err when a request or response is too large to send.
+	ErrFrameTooLarge = errors.New("remote cache frame too large")
+	// ErrClientClosed is returned by Client.Err after the client is closed.
+	ErrClientClosed = errors.New("remote cache client is closed")
+)
+
 // New starts the cache routine and returns a struct to get data from the cache.
 // You do not need to call Start() after calling New(); it's already started.
 func New(config Config) *Cache {
 	return newWithContext(context.Background(), config)
 }
 
+// NewE checks the config and returns an error if any setting is invalid or contradicts
+// another setting, instead of quietly changing it like New() does. Otherwise, it starts
+// the cache routine like New(). Every problem is included in the error, and each one
+// wraps ErrInvalidConfig. Options passed when saving items, like Expire, are not checked.
+func NewE(config Config) (*Cache, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return New(config), nil
+}
+
 // NewWithContext starts the cache routine and returns a struct to get data from the cache.
 // You do not need to call Start() after calling New(); it's already started.
 // If the context is cancelled or times out the cache processor exits.
@@ -105,11 +383,86 @@ func newWithContext(ctx context.Context, config Config) *Cache {
 	cache := newCache(&config)
 	cache.start(ctx)
 
+	if config.Name != "" {
+		register(cache)
+	}
+
 	return cache
 }
 
 // newCache runs once from New() and turns a *Config into a *Cache you can Start().
 func newCache(conf *Config) *Cache {
+	conf.defaults()
+
+	if conf.Clock == nil {
+		conf.Clock = realClock{}
+	}
+
+	if conf.Logger != nil && conf.Name != "" {
+		conf.Logger = conf.Logger.With("cache", conf.Name)
+	}
+
+	if conf.Statsd != nil {
+		statsd := *conf.Statsd // do not change the caller's config.
+		if statsd.Prefix == "" {
+			statsd.Prefix = defaultStatsdPrefix
+		}
+
+		if statsd.Interval <= 0 {
+			statsd.Interval = defaultStatsdInterval
+		}
+
+		conf.Statsd = &statsd
+	}
+
+	if conf.Journal != nil {
+		journal := *conf.Journal // do not change the caller's config.
+		if journal.Codec == nil {
+			journal.Codec = GobCodec{}
+		}
+
+		conf.Journal = &journal
+	}
+
+	shared := &core{
+		conf:      conf,
+		nsStats:   make(map[string]*Stats),
+		deltas:    make(map[string]Stats),
+		watchers:  make(map[string][]*watcher),
+		refreshed: make(chan *req),
+		loads:     make(map[string]*call[*Item]),
+		indexes:   make(map[string]map[string]map[string]struct{}),
+		indexed:   make(map[string]map[string]string),
+	}
+
+	if conf.TinyLFU {
+		shared.sketch = newSketch(conf.InitialCapacity)
+	}
+
+	if conf.MissFilter != nil {
+		filter := *conf.MissFilter // do not change the caller's config.
+		if filter.Keys == 0 {
+			filter.Keys = defaultFilterKeys
+		}
+
+		if filter.FalsePositives == 0 {
+			filter.FalsePositives = defaultFilterRate
+		}
+
+		if filter.Reset == 0 {
+			filter.Reset = defaultFilterReset
+		}
+
+		conf.MissFilter = &filter
+		shared.missFilter = newMissFilter(&filter, conf.Clock.Now())
+	}
+
+	return &Cache{core: shared}
+}
+
+// defaults sets the default values for the settings that can be changed with Reconfigure,
+// and keeps them inside their limits.
+func (conf *Config) defaults() {
 	switch {
 	case conf.RequestAccuracy == 0:
 		conf.RequestAccuracy = defaultAccuracy
@@ -123,6 +476,10 @@ func newCache(conf *Config) *Cache {
 		conf.PruneInterval = minimumPruneDur
 	}
 
+	conf.PruneJitter = min(max(conf.PruneJitter, 0), 1)
+	conf.MemoryPressure = min(max(conf.MemoryPressure, 0), maximumPressure)
+	conf.MemoryFraction = min(max(conf.MemoryFraction, 0), 1)
+
 	// If prune interval is 0, PruneAfter does not control anything.
 	if conf.PruneAfter == 0 {
 		conf.PruneAfter = defaultPruneDur
@@ -132,13 +489,120 @@ func newCache(conf *Config) *Cache {
 	if conf.MaxUnused == 0 {
 		conf.MaxUnused = defaultMaxUnused
 	}
+}
+
+// validate returns the problems with a config that defaults() would quietly change or ignore.
+func (conf *Config) validate() error {
+	var errs []error
+
+	check := func(bad bool, format string, args ...any) {
+		if bad {
+			errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidConfig}, args...)...))
+		}
+	}
 
-	return &Cache{conf: conf}
+	check(conf.PruneInterval < 0 || conf.PruneAfter < 0 || conf.MaxUnused < 0,
+		"PruneInterval, PruneAfter and MaxUnused may not be negative")
+	check(conf.PruneInterval > 0 && conf.PruneInterval < minimumPruneDur,
+		"PruneInterval %v is less than the minimum %v", conf.PruneInterval, minimumPruneDur)
+	check(conf.RequestAccuracy != 0 && (conf.RequestAccuracy < minimumAccuracy || conf.RequestAccuracy > maximumAccuracy),
+		"RequestAccuracy %v is not between %v and %v", conf.RequestAccuracy, minimumAccuracy, maximumAccuracy)
+	check(conf.PruneBatch < 0 || conf.InitialCapacity < 0 || conf.QueueSize < 0 || conf.RequestTimeout < 0 ||
+		conf.MaxCost < 0 || conf.EvictionSamples < 0,
+		"PruneBatch, InitialCapacity, QueueSize, RequestTimeout, MaxCost and EvictionSamples may not be negative")
+	check(conf.PruneJitter < 0 || conf.PruneJitter > 1, "PruneJitter %v is not between 0 and 1", conf.PruneJitter)
+	check(conf.MemoryPressure < 0 || conf.MemoryPressure > maximumPressure,
+		"MemoryPressure %v is not between 0 and %v", conf.MemoryPressure, maximumPressure)
+	check(conf.MemoryFraction < 0 || conf.MemoryFraction > 1, "MemoryFraction %v is not between 0 and 1", conf.MemoryFraction)
+	check(conf.PruneInterval == 0 && (conf.Refresher != nil || conf.PruneBatch > 0 || conf.PruneJitter > 0 ||
+		conf.MemoryPressure > 0 || conf.MemoryFraction > 0 || conf.MaxCost > 0),
+		"Refresher, PruneBatch, PruneJitter, MemoryPressure, MemoryFraction and MaxCost require PruneInterval")
+	check(conf.TinyLFU && conf.Eviction != nil, "TinyLFU and Eviction may not be used together")
+	check(conf.EvictionSamples != 0 && (conf.TinyLFU || conf.Eviction != nil),
+		"EvictionSamples may not be used with TinyLFU or Eviction")
+	check(conf.TinyLFU && conf.MaxCost == 0 && conf.MemoryFraction == 0 && conf.MemoryPressure == 0,
+		"TinyLFU requires MaxCost, MemoryFraction or MemoryPressure")
+	check(conf.Overflow != nil && conf.MaxCost == 0 && conf.MemoryFraction == 0 && conf.MemoryPressure == 0,
+		"Overflow requires MaxCost, MemoryFraction or MemoryPressure")
+	check((conf.StatsInterval > 0) != (conf.OnStats != nil), "StatsInterval and OnStats must be set together")
+	check(conf.Statsd != nil && conf.Statsd.Address == "", "Statsd requires an Address")
+	check(conf.Journal != nil && conf.Journal.Path == "", "Journal requires a Path")
+	check(conf.Journal != nil && conf.Journal.SyncEvery < 0, "Journal SyncEvery may not be negative")
+	check(conf.SnapshotInterval < 0, "SnapshotInterval %v may not be negative", conf.SnapshotInterval)
+	check(conf.SnapshotInterval > 0 && conf.SnapshotPath == "", "SnapshotInterval requires SnapshotPath")
+	check(conf.MissFilter != nil && conf.Loader == nil, "MissFilter requires Loader")
+	check(conf.MissFilter != nil && (conf.MissFilter.Keys < 0 || conf.MissFilter.Reset < 0 ||
+		conf.MissFilter.FalsePositives < 0 || conf.MissFilter.FalsePositives >= 1),
+		"MissFilter Keys and Reset may not be negative, and FalsePositives must be between 0 and 1")
+
+	effective := *conf
+	effective.defaults()
+	check(conf.PruneAfter > 0 && effective.PruneAfter > effective.MaxUnused,
+		"PruneAfter %v is longer than MaxUnused %v, so it has no effect",
+		effective.PruneAfter, effective.MaxUnused)
+
+	return errors.Join(errs...)
+}
+
+// Reconfigure changes the pruner and request timing settings of a running cache,
+// without stopping it. These settings are copied from config, and the defaults are
+// applied to them like New() does: PruneInterval, PruneAfter, MaxUnused, PruneBatch,
+// PruneJitter, MemoryPressure, MemoryFraction, MaxCost and RequestAccuracy. Other settings
+// in config are ignored. The change happens inside the processor, so concurrent
+// requests are not interrupted. On a namespace, this reconfigures the whole cache.
+// This returns ErrStopped if the cache is not running, and the same errors as NewE()
+// if the config is invalid; the cache is not changed if there's an error.
+func (c *Cache) Reconfigure(config Config) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+
+	config.defaults()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.run {
+		return ErrStopped
+	}
+
+	parent := &Cache{core: c.core}
+	parent.send(&req{do: func(_ string, _ time.Time) *Item {
+		if config.RequestAccuracy != c.conf.RequestAccuracy {
+			c.ticks.timer.Reset(config.RequestAccuracy)
+		}
+
+		restart := config.PruneInterval != c.conf.PruneInterval || config.PruneJitter != c.conf.PruneJitter
+
+		c.conf.RequestAccuracy = config.RequestAccuracy
+		c.conf.PruneInterval = config.PruneInterval
+		c.conf.PruneAfter = config.PruneAfter
+		c.conf.MaxUnused = config.MaxUnused
+		c.conf.PruneBatch = config.PruneBatch
+		c.conf.PruneJitter = config.PruneJitter
+		c.conf.MemoryPressure = config.MemoryPressure
+		c.conf.MemoryFraction = config.MemoryFraction
+		c.conf.MaxCost = config.MaxCost
+
+		if restart {
+			c.ticks.pruner.Stop()
+			c.ticks.pruner = noTicker{}
+
+			if c.conf.PruneInterval > 0 {
+				c.ticks.pruner = c.conf.Clock.NewTicker(c.pruneInterval())
+			}
+		}
+
+		return nil
+	}})
+
+	return nil
 }
 
 // Start sets up the cache and starts the go routine using a Background context.
 // Call this only if you already called Stop() and wish to turn it back on.
 // Setting clean will clear the existing cache before restarting.
+// The stats are always kept; use StartWithOptions to reset them.
 func (c *Cache) Start(clean bool) {
 	c.startWithContext(context.Background(), clean)
 }
@@ -151,6 +615,36 @@ func (c *Cache) StartWithContext(ctx context.Context, clean bool) {
 	c.startWithContext(ctx, clean)
 }
 
+// StartOptions control what a restarted cache keeps from before it was stopped.
+type StartOptions struct {
+	// KeepItems keeps the cached items. Otherwise, the cache starts empty.
+	KeepItems bool
+	// KeepStats keeps the stats counters. Otherwise, they start at zero, like after ResetStats().
+	KeepStats bool
+}
+
+// StartWithOptions sets up the cache and starts the go routine with a context, like
+// StartWithContext(), but it controls whether the items and stats are kept separately.
+// Call this only if you already called Stop() and wish to turn it back on.
+func (c *Cache) StartWithOptions(ctx context.Context, opts StartOptions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.run {
+		return // already running, nothing to start.
+	}
+
+	if !opts.KeepItems {
+		c.clean()
+	}
+
+	if !opts.KeepStats {
+		c.resetStats()
+	}
+
+	c.start(ctx)
+}
+
 func (c *Cache) startWithContext(ctx context.Context, clean bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -169,6 +663,7 @@ func (c *Cache) startWithContext(ctx context.Context, clean bool) {
 // Stop stops the go routine and closes the channels.
 // If clean is true it will clean up memory usage and delete the cache.
 // Pass clean if the app will continue to run, and you don't need to re-use the cache data.
+// Named caches are removed from the registry when clean is true.
 func (c *Cache) Stop(clean bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -181,23 +676,186 @@ func (c *Cache) Stop(clean bool) {
 
 	if clean {
 		c.clean()
+
+		if c.conf.Name != "" {
+			unregister(c)
+		}
+	}
+}
+
+// StopAndExport stops the cache like Stop(false), and returns copies of the items that
+// were in it when the processor stopped, so an app can persist or hand them off without
+// racing other requests. Requests already sent are finished first, like Shutdown.
+// On a namespace, this stops the whole cache and only returns the namespace's items.
+// The items are kept in the cache; call Stop(true) after this to free the memory.
+// This returns nil if the cache is not running.
+func (c *Cache) StopAndExport() map[string]*Item {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.run {
+		return nil
+	}
+
+	c.stop()
+
+	return c.list(c.ns).Data.(map[string]*Item) //nolint:forcetypeassert // list always returns a map.
+}
+
+// Shutdown stops the cache gracefully. The processor finishes the requests that were
+// already sent, then stops. Requests sent after Shutdown is called return nil, like
+// a cache miss, instead of causing a panic. Shutdown waits for the processor to stop,
+// or for ctx to be done; then it returns ctx's error, and the processor stops when it
+// finishes the remaining requests. Stop() may be called after that to wait for it.
+// Shutdown does not clean the cache; call Stop(true) after it to free the memory.
+func (c *Cache) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.run {
+		return nil
+	}
+
+	select {
+	case <-c.quit:
+	default:
+		close(c.quit)
+	}
+
+	select {
+	case <-c.stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("shutting down cache: %w", ctx.Err())
+	}
+}
+
+// Namespace returns a view of the cache where every key is transparently prefixed
+// with the namespace name and NamespaceSeparator. The namespace shares the parent
+// cache's processor, so it does not start another go routine. List(), Flush() and
+// Stats() on a namespace only include items and requests inside that namespace.
+// Namespaces may be nested. Calling Start() or Stop() on a namespace starts or stops
+// the shared processor, affecting the parent cache and every other namespace.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Namespace(name string) *Cache {
+	namespace := &Cache{core: c.core, ns: c.ns + name + NamespaceSeparator}
+	namespace.send(&req{do: func(string, time.Time) *Item {
+		if c.nsStats[namespace.ns] == nil {
+			c.nsStats[namespace.ns] = &Stats{}
+		}
+
+		return nil
+	}})
+
+	return namespace
+}
+
+// send a request to the processor and return the response.
+func (c *Cache) send(request *req) *Item {
+	c.prepare(request)
+
+	// Function requests write their results to the caller's variables, so they can not time out.
+	if c.conf.RequestTimeout > 0 && request.do == nil {
+		return c.sendTimeout(request)
+	}
+
+	if c.conf.QueueSize == 0 {
+		select {
+		case c.req <- request:
+			return <-c.res
+		case <-c.stopped:
+			return nil
+		}
+	}
+
+	// Queued requests need their own response channel, so each caller gets its own response.
+	request.res = make(chan *Item, 1)
+
+	select {
+	case c.req <- request:
+	case <-c.stopped:
+		return nil
+	}
+
+	return c.response(request)
+}
+
+// response returns the response to a queued request, or nil if the processor stopped without
+// processing it.
+func (c *Cache) response(request *req) *Item {
+	select {
+	case item := <-request.res:
+		return item
+	case <-c.stopped:
+		select {
+		case item := <-request.res: // the response was sent before it stopped.
+			return item
+		default:
+			return nil
+		}
 	}
 }
 
 // Get returns a pointer to a copy of an item, or nil if it doesn't exist.
 // This library will not read or write to the item after it's returned.
+// With Config.Loader, missing keys are loaded like Load does, and Loader errors are logged.
 // Calling this procedure after calling Stop() or cancelling the context produces a panic.
 func (c *Cache) Get(requestKey string) *Item {
-	c.req <- &req{key: requestKey, get: true}
-	return <-c.res
+	if c.missFilter.has(c.ns + requestKey) {
+		return nil
+	}
+
+	if item := c.send(&req{key: requestKey, get: true}); item != nil || c.conf.Loader == nil {
+		return item
+	}
+
+	return c.readThrough(requestKey)
+}
+
+// GetData returns the data for a key, and false if it doesn't exist. It updates hit/miss
+// stats like Get(), but it does not copy the item, so it saves an allocation on each call
+// for large, read-mostly caches. Like Get(), the data itself is not copied; do not change
+// data that pointers, maps or slices refer to. The cache never changes the returned value.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) GetData(requestKey string) (any, bool) {
+	if c.missFilter.has(c.ns + requestKey) {
+		return nil, false
+	}
+
+	item := c.send(&req{key: requestKey, get: true, ref: true})
+	if item == nil && c.conf.Loader != nil {
+		item = c.readThrough(requestKey)
+	}
+
+	if item == nil {
+		return nil, false
+	}
+
+	return item.Data, true
 }
 
 // Save saves an item, and returns true if it already existed (got updated).
 // This procedure does NOT update hit/miss stats like cache.Get() does.
 // Calling this procedure after calling Stop() or cancelling the context produces a panic.
 func (c *Cache) Save(requestKey string, data any, opts Options) bool {
-	c.req <- &req{key: requestKey, data: data, opts: &opts}
-	return <-c.res != nil
+	return c.send(&req{key: requestKey, data: data, opts: &opts}) != nil
+}
+
+// SaveNegative caches a "not found" or error result for a key, so repeated lookups for
+// it do not need to reach the origin. The item is returned by Get() with Negative set
+// to true and Data set to err. If err is nil, ErrKeyNotFound is saved instead.
+// The item expires after ttl, like Options.Expire.
+// Returns true if the key already existed (got updated).
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) SaveNegative(requestKey string, err error, ttl time.Duration) bool {
+	if err == nil {
+		err = ErrKeyNotFound
+	}
+
+	opts := Options{Expire: c.conf.Clock.Now().Add(ttl)}
+
+	return c.send(&req{key: requestKey, data: err, opts: &opts, negative: true}) != nil
 }
 
 // Update saves an item, and returns a copy of the previously saved item.
@@ -206,18 +864,447 @@ func (c *Cache) Save(requestKey string, data any, opts Options) bool {
 // Check the item for nil to determine if it existed prior to this call.
 // Calling this procedure after calling Stop() or cancelling the context produces a panic.
 func (c *Cache) Update(requestKey string, data any, opts Options) *Item {
-	c.req <- &req{key: requestKey, get: true, data: data, opts: &opts}
-	return <-c.res
+	return c.send(&req{key: requestKey, get: true, data: data, opts: &opts})
+}
+
+// Replace saves an item only if the key already exists, and returns true if it was updated.
+// If the key does not exist nothing is saved and false is returned.
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Replace(requestKey string, data any, opts Options) bool {
+	return c.send(&req{key: requestKey, data: data, opts: &opts, exist: true}) != nil
+}
+
+// CompareAndSwap saves data only if the currently cached data is equal to oldData.
+// Returns true if the swap happened. Pass a nil oldData to save only when the key does not exist.
+// Uncomparable values (slices, maps, funcs) never match, and passing a nil newData deletes the key.
+// Passing nil for both oldData and newData does nothing and returns false.
+// The compare and the swap happen atomically inside the cache processor.
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) CompareAndSwap(requestKey string, oldData, newData any, opts Options) bool {
+	return c.send(&req{key: requestKey, do: func(key string, now time.Time) *Item {
+		return c.compareAndSwap(key, oldData, newData, &opts, now)
+	}}) != nil
+}
+
+// SaveIfVersion saves data only if the cached item's Version equals version, and returns
+// true if it was saved. Pass a zero version to save only when the key does not exist.
+// Versions come from a counter shared by every key, so a key that's deleted and saved
+// again never gets a Version it had before, and a stale version never matches.
+// Get an item, change its data, and save it with SaveIfVersion to detect lost updates.
+// Like Save, passing nil data deletes the key (if the version matches).
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) SaveIfVersion(requestKey string, data any, version int64, opts Options) bool {
+	return c.send(&req{key: requestKey, do: func(key string, now time.Time) *Item {
+		item := c.cache.Get(key)
+
+		switch {
+		case item == nil && version != 0, item != nil && item.Version != version:
+			return nil
+		case data == nil && item == nil:
+			return nil // nil data is never saved; it means delete.
+		case data == nil:
+			return c.delete(key, now)
+		default:
+			c.save(&req{key: key, data: data, opts: &opts}, now, false)
+			return &Item{} // Return a non-nil item; the key may not have existed.
+		}
+	}}) != nil
+}
+
+// Once returns true the first time a key is seen within window, and false until the window
+// passes. The check and the save happen atomically inside the cache processor, so when many
+// go routines call Once with the same key, only one gets true. Use it to deduplicate alerts,
+// or to debounce events. The key is saved with true as Data and expires after window.
+// A window of 0 or less does not deduplicate: Once returns true, and does not save the key.
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Once(requestKey string, window time.Duration) bool {
+	if window <= 0 {
+		return true
+	}
+
+	return c.send(&req{key: requestKey, do: func(key string, now time.Time) *Item {
+		if item := c.cache.Get(key); item != nil {
+			if item.opts.Expire.IsZero() || !now.After(item.opts.Expire) {
+				return nil
+			}
+
+			c.remove(key, expired, now)
+		}
+
+		c.save(&req{key: key, data: true, opts: &Options{TTL: window}}, now, false)
+
+		return &Item{} // Return a non-nil item; the key did not exist.
+	}}) != nil
+}
+
+// Expire changes the expiration time of an existing item without changing its data,
+// and returns true if the item exists. Pass a zero time to remove the expiration.
+// Like Options.Expire, the item is removed by the pruner or when it's retrieved.
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Expire(requestKey string, expire time.Time) bool {
+	return c.send(&req{key: requestKey, do: func(key string, now time.Time) *Item {
+		item := c.cache.Get(key)
+		if item == nil {
+			return nil
+		}
+
+		opts := *item.opts
+		opts.Expire = expire
+		item.opts = &opts
+		c.expireLater(key, item)
+		c.journalItem(key, item, now)
+
+		return item
+	}}) != nil
+}
+
+// Pin pins or unpins an existing item without changing its data, and returns true if the item exists.
+// Pinned items are never pruned for being unused, and never evicted for memory. See Options.Pin.
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Pin(requestKey string, pin bool) bool {
+	return c.send(&req{key: requestKey, do: func(key string, now time.Time) *Item {
+		item := c.cache.Get(key)
+		if item == nil {
+			return nil
+		}
+
+		opts := *item.opts
+		opts.Pin = pin
+		item.opts = &opts
+
+		if policy := c.conf.Eviction; policy != nil && pin {
+			policy.OnDelete(key)
+		} else if policy != nil {
+			policy.OnSave(key)
+		}
+
+		c.journalItem(key, item, now)
+
+		return item
+	}}) != nil
+}
+
+// TTL returns how long until an item expires, and false if the key does not exist.
+// Items without an expiration time return Forever. Items past their expiration time
+// that have not been pruned yet return a negative duration.
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) TTL(requestKey string) (time.Duration, bool) {
+	var expire time.Time
+
+	item := c.send(&req{key: requestKey, do: func(key string, _ time.Time) *Item {
+		item := c.cache.Get(key)
+		if item != nil {
+			expire = item.opts.Expire
+		}
+
+		return item
+	}})
+
+	switch {
+	case item == nil:
+		return 0, false
+	case expire.IsZero():
+		return Forever, true
+	default:
+		return expire.Sub(c.conf.Clock.Now()), true
+	}
 }
 
 // Delete removes an item and returns true if it existed.
 // Calling this procedure after calling Stop() or cancelling the context produces a panic.
 func (c *Cache) Delete(requestKey string) bool {
-	c.req <- &req{key: requestKey}
-	return <-c.res != nil
+	return c.send(&req{key: requestKey}) != nil
+}
+
+// CompareAndDelete removes an item only if the cached data is equal to expected.
+// Returns true if the item was deleted. Uncomparable values (slices, maps, funcs) never match.
+// The compare and the delete happen atomically inside the cache processor.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) CompareAndDelete(requestKey string, expected any) bool {
+	return c.send(&req{key: requestKey, do: func(key string, now time.Time) *Item {
+		if item := c.cache.Get(key); item == nil || !equal(item.Data, expected) {
+			return nil
+		}
+
+		return c.delete(key, now)
+	}}) != nil
+}
+
+// Increment atomically adds delta to an integer stored in cache and returns the new value.
+// Pass a negative delta to decrement. If the key does not exist it's created with delta as the value.
+// The stored integer type (int, uint32, int64, etc) is preserved, and ErrNotInteger is
+// returned (with the current value unchanged) if the cached data is not an integer.
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Increment(requestKey string, delta int64) (int64, error) {
+	var (
+		value int64
+		err   error
+	)
+
+	c.send(&req{key: requestKey, do: func(key string, now time.Time) *Item {
+		value, err = c.increment(key, delta, now)
+		return nil
+	}})
+
+	return value, err
+}
+
+// Append atomically appends suffix to a string or []byte stored in cache.
+// The suffix may be a string or []byte and the stored type is preserved.
+// If the key does not exist it's created with suffix as the value.
+// ErrNotAppendable is returned (with the current value unchanged) if either value is another type.
+// Byte slices are always copied, so slices previously returned by Get() are never modified.
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Append(requestKey string, suffix any) error {
+	var err error
+
+	c.send(&req{key: requestKey, do: func(key string, now time.Time) *Item {
+		err = c.append(key, suffix, now)
+		return nil
+	}})
+
+	return err
+}
+
+// Rename atomically moves an item to a new key, keeping its Hits, Time and Options.
+// Returns ErrKeyNotFound if oldKey does not exist, or ErrKeyExists if newKey
+// exists and overwrite is false. When an existing item at newKey is overwritten it's deleted
+// first, so it counts as a delete in stats and its subscribers and watchers see a delete.
+// No other stats are updated by this procedure.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Rename(oldKey, newKey string, overwrite bool) error {
+	var err error
+
+	newKey = c.ns + newKey
+
+	c.send(&req{key: oldKey, do: func(oldKey string, now time.Time) *Item {
+		c.unspill(newKey, now) // an item stored in the Overflow at newKey exists too.
+
+		switch item := c.cache.Get(oldKey); {
+		case item == nil:
+			err = ErrKeyNotFound
+		case oldKey == newKey:
+		case c.cache.Get(newKey) != nil && !overwrite:
+			err = ErrKeyExists
+		default:
+			if c.cache.Get(newKey) != nil {
+				c.delete(newKey, now) // overwritten.
+			}
+
+			c.cache.Set(newKey, item)
+			c.cache.Delete(oldKey)
+			c.expireLater(newKey, item)
+			c.emit(EventDelete, oldKey, item, now)
+			c.emit(EventSave, newKey, item, now)
+		}
+
+		return nil
+	}})
+
+	return err
+}
+
+// Compute atomically reads an item, passes a copy of it (or nil if it doesn't exist) to fn,
+// and saves the data and options returned by fn. If fn returns nil data or false for keep,
+// the item is deleted instead. fn runs inside the cache processor, so no other request is
+// processed until it returns; calling any cache method from inside fn causes a deadlock.
+// If fn panics, the panic is recovered and logged, and the item is not changed.
+// This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Compute(requestKey string, fn func(old *Item) (data any, opts Options, keep bool)) {
+	c.send(&req{key: requestKey, do: func(key string, now time.Time) *Item {
+		var old *Item
+		if item := c.cache.Get(key); item != nil {
+			old = c.copy(item)
+		}
+
+		if data, opts, keep := fn(old); keep && data != nil {
+			c.save(&req{key: key, data: data, opts: &opts}, now, false)
+		} else if old != nil {
+			c.delete(key, now)
+		}
+
+		return nil
+	}})
+}
+
+// Flush deletes every item in the cache, or in the namespace, and returns the number of items deleted.
+// This procedure does NOT update delete stats like cache.Delete() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Flush() int {
+	return counted(c.send(&req{do: func(prefix string, now time.Time) *Item {
+		return &Item{Hits: int64(c.flush(prefix, now))}
+	}}))
+}
+
+// Prune runs the pruner now and returns the number of items pruned or expired.
+// This works if the pruner routine is not enabled, using the PruneAfter and MaxUnused
+// settings, and it also starts refreshes if a Refresher is configured.
+// When called on a namespace, the whole cache is pruned, because the pruner is shared.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Prune() (pruned int) {
+	parent := &Cache{core: c.core}
+
+	return counted(parent.send(&req{do: func(_ string, now time.Time) *Item {
+		before := c.stats.Pruned
+		start := time.Now()
+		c.prune(&now, 0)
+		c.stats.Pruning.Duration += time.Since(start)
+
+		return &Item{Hits: c.stats.Pruned - before}
+	}}))
+}
+
+// PruneFunc deletes every item for which fn returns true, and returns the number deleted.
+// Use it for app-specific eviction rules. fn receives each key, without a namespace prefix,
+// and a copy of its item. Deleted items are counted in Pruned and PrunedFunc, and
+// subscribers receive EventPrune for them. fn runs inside the cache processor, so calling
+// any cache method from inside fn causes a deadlock. When called on a namespace,
+// only items in that namespace are checked.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) PruneFunc(fn func(key string, item *Item) bool) int {
+	return counted(c.send(&req{do: func(prefix string, now time.Time) *Item {
+		var count int64
+
+		c.cache.Iterate(func(key string, item *Item) bool {
+			if !strings.HasPrefix(key, prefix) || !fn(strings.TrimPrefix(key, prefix), c.copy(item)) {
+				return true
+			}
+
+			c.remove(key, custom, now)
+			count++
+
+			return true
+		})
+
+		return &Item{Hits: count}
+	}}))
+}
+
+// counted returns the count a processor function returned in Hits,
+// or 0 if the processor stopped before it ran.
+func counted(res *Item) int {
+	if res == nil {
+		return 0
+	}
+
+	return int(res.Hits)
+}
+
+// Keys returns a sorted list of the keys in the cache, without copying any items.
+// When called on a namespace, only the keys in that namespace are returned,
+// and the namespace prefix is removed from them.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Keys() []string {
+	return c.KeysWithPrefix("")
+}
+
+// KeysWithPrefix returns a sorted list of the keys that start with prefix, without copying
+// any items. When called on a namespace, only the keys in that namespace are checked,
+// and the namespace prefix (not the prefix argument) is removed from them.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) KeysWithPrefix(prefix string) []string {
+	var keys []string
+
+	c.send(&req{do: func(namespace string, _ time.Time) *Item {
+		size := 0
+		if prefix == "" {
+			size = c.count(namespace)
+		}
+
+		keys = make([]string, 0, size)
+
+		c.cache.Iterate(func(key string, _ *Item) bool {
+			if strings.HasPrefix(key, namespace+prefix) {
+				keys = append(keys, strings.TrimPrefix(key, namespace))
+			}
+
+			return true
+		})
+
+		return nil
+	}})
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Search returns a sorted list of the keys that match a glob pattern, without copying any
+// items. The pattern syntax is path.Match's: * matches any characters except a slash,
+// ? matches one of them, and [a-z] matches a range. A malformed pattern matches no keys.
+// When called on a namespace, the pattern is matched against keys without the
+// namespace prefix, and only the keys in that namespace are returned.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Search(pattern string) []string {
+	return c.search(func(key string) bool {
+		matched, _ := path.Match(pattern, key)
+		return matched
+	})
+}
+
+// SearchRegexp is like Search, but returns the keys that match a regular expression.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) SearchRegexp(pattern *regexp.Regexp) []string {
+	return c.search(pattern.MatchString)
+}
+
+// search returns a sorted list of the keys, without a namespace prefix, that match.
+func (c *Cache) search(match func(key string) bool) []string {
+	keys := []string{}
+
+	c.send(&req{do: func(namespace string, _ time.Time) *Item {
+		c.cache.Iterate(func(key string, _ *Item) bool {
+			if strings.HasPrefix(key, namespace) && match(strings.TrimPrefix(key, namespace)) {
+				keys = append(keys, strings.TrimPrefix(key, namespace))
+			}
+
+			return true
+		})
+
+		return nil
+	}})
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ListPrefix returns copies of the items with keys that start with prefix. The map and the
+// items in it will never be nil. Only the matching items are copied, so this is cheaper
+// than filtering the List. When called on a namespace, only the items in that namespace
+// are checked, and the namespace prefix (not the prefix argument) is removed from the keys.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) ListPrefix(prefix string) map[string]*Item {
+	items := make(map[string]*Item)
+
+	c.send(&req{do: func(namespace string, _ time.Time) *Item {
+		c.cache.Iterate(func(key string, item *Item) bool {
+			if strings.HasPrefix(key, namespace+prefix) {
+				items[strings.TrimPrefix(key, namespace)] = c.copy(item)
+			}
+
+			return true
+		})
+
+		return nil
+	}})
+
+	return items
 }
 
 // List returns a copy of the in-memory cache. The map list will never be nil.
+// When called on a namespace, only the items in that namespace are returned,
+// and the namespace prefix is removed from the keys.
 // This library will not read or write to the map after it's returned.
 // The map Items will also never be nil, and because they are copies,
 // this library will not read or write to them after they're returned.
@@ -226,8 +1313,143 @@ func (c *Cache) Delete(requestKey string) bool {
 // not want to call this method much, or at all.
 // Calling this procedure after calling Stop() or cancelling the context produces a panic.
 func (c *Cache) List() map[string]*Item {
-	c.req <- &req{list: true}
-	items, _ := (<-c.res).Data.(map[string]*Item)
+	if res := c.send(&req{list: true}); res != nil {
+		return res.Data.(map[string]*Item) //nolint:forcetypeassert // list always returns a map.
+	}
+
+	return map[string]*Item{} // the processor stopped.
+}
+
+// KeyedItem is an item copy and its key, sent by Stream.
+type KeyedItem struct {
+	Key  string
+	Item *Item
+}
+
+// streamBatch is the number of items Stream copies with each request to the processor.
+const streamBatch = 100
+
+// Stream sends copies of the items in the cache on the returned channel, sorted by key.
+// The keys are listed when Stream is called, and the items are copied 100 at
+// a time while the channel is read, so memory use does not grow with the size of the
+// items in the cache. Items deleted before they are copied are skipped, and items saved
+// after Stream is called are not sent. The channel is closed after the last item, when
+// ctx is done, or when the cache stops. Cancel ctx if you stop reading before the end.
+// When called on a namespace, only the items in that namespace are sent,
+// and the namespace prefix is removed from the keys.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Stream(ctx context.Context) <-chan KeyedItem {
+	keys := c.Keys()
+	stream := make(chan KeyedItem)
+
+	go func() {
+		defer close(stream)
+
+		for len(keys) > 0 {
+			batch := keys[:min(streamBatch, len(keys))]
+			keys = keys[len(batch):]
+
+			copied := c.send(&req{do: func(prefix string, _ time.Time) *Item {
+				items := make([]KeyedItem, 0, len(batch))
+
+				for _, key := range batch {
+					if item := c.cache.Get(prefix + key); item != nil {
+						items = append(items, KeyedItem{Key: key, Item: c.copy(item)})
+					}
+				}
+
+				return &Item{Data: items}
+			}})
+			if copied == nil {
+				return // the cache stopped.
+			}
+
+			for _, item := range copied.Data.([]KeyedItem) { //nolint:forcetypeassert // always this type.
+				select {
+				case stream <- item:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return stream
+}
+
+// ForEach calls fn with each key, without a namespace prefix, and a copy of its item,
+// until fn returns false. Only one item is copied at a time, so unlike List this does
+// not double the memory footprint of the cache. Items are not visited in any order.
+// fn runs inside the cache processor, so calling any cache method from inside fn causes
+// a deadlock, and other requests wait until ForEach returns. When called on a namespace,
+// only the items in that namespace are visited.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) ForEach(fn func(key string, item Item) bool) {
+	c.send(&req{do: func(prefix string, _ time.Time) *Item {
+		c.cache.Iterate(func(key string, item *Item) bool {
+			return !strings.HasPrefix(key, prefix) || fn(strings.TrimPrefix(key, prefix), *c.copy(item))
+		})
+
+		return nil
+	}})
+}
+
+// ListWhere returns copies of the items for which fn returns true. fn receives each key,
+// without a namespace prefix, and a shallow copy of its item, so only the matching items
+// are deep copied (with Config.DeepCopy) and returned. fn must not change the item Data.
+// fn runs inside the cache processor, so calling any cache method from inside fn causes
+// a deadlock. The map and the items in it will never be nil. When called on a namespace,
+// only the items in that namespace are checked, and the namespace prefix is removed from the keys.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) ListWhere(fn func(key string, item *Item) bool) map[string]*Item {
+	items := make(map[string]*Item)
+
+	c.send(&req{do: func(prefix string, _ time.Time) *Item {
+		c.cache.Iterate(func(key string, item *Item) bool {
+			if strings.HasPrefix(key, prefix) && fn(strings.TrimPrefix(key, prefix), item.copy()) {
+				items[strings.TrimPrefix(key, prefix)] = c.copy(item)
+			}
+
+			return true
+		})
+
+		return nil
+	}})
 
 	return items
 }
+
+// ListAfter returns a page of copies of up to limit items, with the first keys sorted after
+// the after key, and the key to pass as after to get the next page. Pass an empty after key
+// to get the first page. next is empty after the last page. Use this to page through a large
+// cache without copying all of it like List does. Keys are only compared, so items saved or
+// deleted between calls may or may not be in later pages, but no key is returned twice.
+// When called on a namespace, only the items in that namespace are returned,
+// and the namespace prefix is removed from the keys.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) ListAfter(after string, limit int) (items map[string]*Item, next string) {
+	items = make(map[string]*Item)
+	if limit <= 0 {
+		return items, ""
+	}
+
+	c.send(&req{do: func(prefix string, now time.Time) *Item {
+		page := c.rank(limit+1, prefix, now, func(a, b *KeyStat) bool { return a.Key < b.Key },
+			func(key string, _ *Item) bool { return after != "" && strings.TrimPrefix(key, prefix) <= after },
+		).stats
+
+		sort.Slice(page, func(i, j int) bool { return page[i].Key < page[j].Key })
+
+		if len(page) > limit {
+			page, next = page[:limit], page[limit-1].Key
+		}
+
+		for _, stat := range page {
+			items[stat.Key] = c.copy(c.cache.Get(prefix + stat.Key))
+		}
+
+		return nil
+	}})
+
+	return items, next
+}
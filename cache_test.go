@@ -1,9 +1,29 @@
 package cache_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+	"time"
 
 	"golift.io/cache"
+	"golift.io/cache/cachetest"
 )
 
 func ExampleNew() {
@@ -36,3 +56,1900 @@ func ExampleNew() {
 	// Del: 1
 	// Size: 1
 }
+
+func ExampleNewE() {
+	_, err := cache.NewE(cache.Config{PruneAfter: 2 * time.Hour, MaxUnused: time.Hour})
+	fmt.Println(errors.Is(err, cache.ErrInvalidConfig))
+	fmt.Println(err)
+
+	users, err := cache.NewE(cache.Config{PruneInterval: time.Minute, PruneAfter: 10 * time.Minute})
+	fmt.Println(err)
+	users.Stop(true)
+	// Output:
+	// true
+	// invalid cache config: PruneAfter 2h0m0s is longer than MaxUnused 1h0m0s, so it has no effect
+	// <nil>
+}
+
+func ExampleGet() {
+	users := cache.New(cache.Config{Name: "users"})
+	users.Save("admin", "Super Dooper", cache.Options{})
+
+	// Elsewhere in the app, find the cache by name.
+	fmt.Println("User:", cache.Get("users").Get("admin").Data)
+
+	// The stats for every named cache are published to expvar.
+	stats, _ := expvar.Get(cache.ExpvarName).(expvar.Func)().(map[string]*cache.Stats)
+	fmt.Println("Hits:", stats["users"].Hits)
+
+	users.Stop(true)
+	fmt.Println("Registered:", cache.Get("users") != nil)
+	// Output:
+	// User: Super Dooper
+	// Hits: 1
+	// Registered: false
+}
+
+func ExampleStatsdConfig() {
+	server, _ := net.ListenPacket("udp", "127.0.0.1:0")
+	defer server.Close()
+
+	users := cache.New(cache.Config{Statsd: &cache.StatsdConfig{
+		Address:  server.LocalAddr().String(),
+		Interval: 100 * time.Millisecond,
+		Tags:     []string{"env:test"},
+	}})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Get("admin")
+	users.Get("luser")
+
+	packet := make([]byte, 1024)
+	size, _, _ := server.ReadFrom(packet)
+	fmt.Println(string(packet[:size]))
+	// Output:
+	// cache.size:1|g|#env:test
+	// cache.hits:1|c|#env:test
+	// cache.misses:1|c|#env:test
+	// cache.saves:1|c|#env:test
+	// cache.updates:0|c|#env:test
+	// cache.deletes:0|c|#env:test
+	// cache.pruned:0|c|#env:test
+	// cache.prunes:0|c|#env:test
+	// cache.pruning_ms:0|c|#env:test
+}
+
+func ExampleConfig_logger() {
+	// Remove the time and stack trace from log lines, so the output does not change.
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			switch attr.Key {
+			case slog.TimeKey:
+				return slog.Attr{}
+			case "stack":
+				return slog.String("stack", "...")
+			default:
+				return attr
+			}
+		},
+	}))
+
+	users := cache.New(cache.Config{Name: "logged", Logger: logger})
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Compute("admin", func(*cache.Item) (any, cache.Options, bool) {
+		panic("oops")
+	})
+	fmt.Println("User:", users.Get("admin").Data)
+	users.Stop(true)
+	// Output:
+	// level=INFO msg="cache started" cache=logged size=0
+	// level=ERROR msg="recovered panic in cache processor" cache=logged key=admin panic=oops stack=...
+	// User: Super Dooper
+	// level=INFO msg="cache stopped" cache=logged size=1
+}
+
+func ExampleConfig_onStats() {
+	snapshots := make(chan cache.Stats, 1)
+	users := cache.New(cache.Config{
+		StatsInterval: 100 * time.Millisecond,
+		OnStats: func(stats cache.Stats) {
+			select {
+			case snapshots <- stats:
+			default: // only the first snapshot is printed.
+			}
+		},
+	})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Get("admin")
+	users.Get("luser")
+
+	stats := <-snapshots
+	fmt.Println("Size:", stats.Size, "Gets:", stats.Gets, "Hits:", stats.Hits)
+	// Output:
+	// Size: 1 Gets: 2 Hits: 1
+}
+
+func ExampleConfig_trackLatency() {
+	users := cache.New(cache.Config{TrackLatency: true})
+	defer users.Stop(true)
+
+	for i := 0; i < 100; i++ {
+		users.Save("admin", "Super Dooper", cache.Options{})
+		users.Get("admin")
+	}
+
+	stats := users.Stats()
+	// Percentiles depend on the machine, so this example only checks they're in order.
+	fmt.Println("Work p50 <= p99:", stats.Work.P50.Duration <= stats.Work.P99.Duration)
+	fmt.Println("Wait p99 <= max:", stats.Wait.P99.Duration <= stats.Wait.Max.Duration)
+	fmt.Println("Recorded:", stats.Work.Max.Duration > 0)
+	// Output:
+	// Work p50 <= p99: true
+	// Wait p99 <= max: true
+	// Recorded: true
+}
+
+// fixedClock is a Clock that always returns the same time, and its tickers never fire.
+type fixedClock struct{ now time.Time }
+
+type stoppedTicker struct{}
+
+func (f fixedClock) Now() time.Time                       { return f.now }
+func (f fixedClock) NewTicker(time.Duration) cache.Ticker { return stoppedTicker{} }
+func (stoppedTicker) C() <-chan time.Time                 { return nil }
+func (stoppedTicker) Stop()                               {}
+func (stoppedTicker) Reset(time.Duration)                 {}
+
+func ExampleClock() {
+	clock := fixedClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	users := cache.New(cache.Config{Clock: clock})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{Expire: clock.now.Add(time.Hour)})
+	ttl, _ := users.TTL("admin")
+
+	fmt.Println("Saved:", users.Get("admin").Time)
+	fmt.Println("TTL:", ttl)
+	// Output:
+	// Saved: 2024-01-02 03:04:05 +0000 UTC
+	// TTL: 1h0m0s
+}
+
+func ExampleConfig_queueSize() {
+	counters := cache.New(cache.Config{QueueSize: 64})
+	defer counters.Stop(true)
+
+	var wg sync.WaitGroup
+
+	// Bursts of requests wait in the queue, and each caller still gets its own response.
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			counters.Increment("requests", 1)
+		}()
+	}
+
+	wg.Wait()
+
+	value, err := counters.Increment("requests", 0)
+	fmt.Println("Requests:", value, err)
+	// Output:
+	// Requests: 100 <nil>
+}
+
+func ExampleCache_GetContext() {
+	users := cache.New(cache.Config{RequestTimeout: 50 * time.Millisecond})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+
+	item, err := users.GetContext(context.Background(), "admin")
+	fmt.Println(item.Data, err)
+
+	// Stall the processor with a slow Compute function.
+	started := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		users.Compute("admin", func(old *cache.Item) (any, cache.Options, bool) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			return old.Data, cache.Options{}, true
+		})
+	}()
+
+	<-started
+
+	_, err = users.GetContext(context.Background(), "admin")
+	fmt.Println(errors.Is(err, cache.ErrRequestTimeout))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = users.SaveContext(ctx, "luser", "Under Dawggy", cache.Options{})
+	fmt.Println(err)
+	<-done
+	// Output:
+	// Super Dooper <nil>
+	// true
+	// cache request: context canceled
+}
+
+func TestRequestTimeoutFunctions(t *testing.T) {
+	t.Parallel()
+
+	counters := cache.New(cache.Config{RequestTimeout: 10 * time.Millisecond})
+	t.Cleanup(func() { counters.Stop(true) })
+
+	// Stall the processor for longer than the RequestTimeout.
+	started := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		counters.Compute("slow", func(*cache.Item) (any, cache.Options, bool) {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			return 1, cache.Options{}, true
+		})
+	}()
+
+	<-started
+
+	// Increment waits for the processor instead of returning a zero value as a success.
+	if value, err := counters.Increment("visits", 2); value != 2 || err != nil {
+		t.Errorf("Increment returned %d, %v after the RequestTimeout; expected 2, <nil>", value, err)
+	}
+
+	<-done
+}
+
+// profile is cached data that can make a deep copy of itself.
+type profile struct{ Roles []string }
+
+func (p *profile) Clone() any {
+	return &profile{Roles: append([]string{}, p.Roles...)}
+}
+
+func ExampleCloner() {
+	users := cache.New(cache.Config{DeepCopy: true})
+	defer users.Stop(true)
+
+	users.Save("admin", &profile{Roles: []string{"admin"}}, cache.Options{})
+
+	// Changing a copy does not change the cached profile.
+	users.Get("admin").Data.(*profile).Roles[0] = "guest"           //nolint:forcetypeassert
+	fmt.Println("Roles:", users.Get("admin").Data.(*profile).Roles) //nolint:forcetypeassert
+	// Output:
+	// Roles: [admin]
+}
+
+func ExampleCache_Stats() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	fmt.Println("Ratio without gets:", users.Stats().HitRatio)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Get("admin")
+	users.Get("admin")
+	users.Get("admin")
+	users.Get("luser")
+	fmt.Println("Ratio:", users.Stats().HitRatio)
+	// Output:
+	// Ratio without gets: 0
+	// Ratio: 0.75
+}
+
+func ExampleStats_pruned() {
+	sessions := cache.New(cache.Config{
+		RequestAccuracy: 100 * time.Millisecond,
+		PruneAfter:      100 * time.Millisecond,
+		MaxUnused:       time.Minute,
+	})
+	defer sessions.Stop(true)
+
+	sessions.Save("token", "abc", cache.Options{Expire: time.Now().Add(50 * time.Millisecond)})
+	sessions.Save("visitor", "guest", cache.Options{Prune: true})
+	sessions.Save("admin", "Super Dooper", cache.Options{})
+	time.Sleep(350 * time.Millisecond)
+	sessions.Prune()
+
+	stats := sessions.Stats()
+	fmt.Println("Pruned:", stats.Pruned, "Expired:", stats.Expired,
+		"Unused:", stats.PrunedUnused, "Prunable:", stats.PrunedPrunable)
+	// Output:
+	// Pruned: 2 Expired: 1 Unused: 0 Prunable: 1
+}
+
+func TestPrunedReasons(t *testing.T) {
+	t.Parallel()
+
+	clock := cachetest.NewClock()
+	users := cache.New(cache.Config{PruneInterval: time.Hour, PruneAfter: time.Minute, MaxUnused: time.Hour, Clock: clock})
+	t.Cleanup(func() { users.Stop(true) })
+
+	admins := users.Namespace("admins")
+	admins.Save("root", "Super Dooper", cache.Options{})
+	admins.Save("root", "Super Duper", cache.Options{}) // replaced.
+	users.Save("token", "abc", cache.Options{TTL: time.Second})
+	users.Save("visitor", "guest", cache.Options{Prune: true})
+	users.Save("bot", "crawler", cache.Options{})
+	users.Save("bot", "spider", cache.Options{}) // replaced.
+	users.PruneFunc(func(key string, _ *cache.Item) bool { return key == "bot" })
+	clock.Advance(2 * time.Minute)
+	users.Prune()
+
+	stats := users.Stats()
+	if sum := stats.Expired + stats.PrunedUnused + stats.PrunedPrunable + stats.PrunedFunc +
+		stats.Evicted + stats.Replaced; sum != stats.Pruned || stats.Pruned != 5 {
+		t.Errorf("pruned reasons add up to %d, Pruned is %d, expected 5: %+v", sum, stats.Pruned, stats)
+	}
+
+	if stats.Replaced != 2 || stats.Updates != 2 {
+		t.Errorf("expected 2 replaced items and 2 updates: %+v", stats)
+	}
+
+	if ns := admins.Stats(); ns.Replaced != 1 || ns.Pruned != 1 {
+		t.Errorf("expected 1 replaced item in the namespace: %+v", ns)
+	}
+}
+
+func ExampleDuration() {
+	var stats cache.Stats
+
+	err := json.Unmarshal([]byte(`{"Pruning":"1m30s"}`), &stats)
+	fmt.Println(stats.Pruning, err)
+
+	flags := flag.NewFlagSet("example", flag.ContinueOnError)
+	flags.Var(&stats.Pruning, "pruning", "time spent pruning")
+	err = flags.Parse([]string{"-pruning", "2h"})
+	fmt.Println(stats.Pruning, err)
+
+	text, err := stats.Pruning.MarshalText()
+	fmt.Println(string(text), err)
+	// Output:
+	// 1m30s <nil>
+	// 2h0m0s <nil>
+	// 2h0m0s <nil>
+}
+
+func ExampleStats_String() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+
+	for i := 0; i < 4500; i++ {
+		users.Get("admin")
+	}
+
+	for i := 0; i < 312; i++ {
+		users.Get("luser")
+	}
+
+	fmt.Println(users.Stats())
+	// Output:
+	// size=1 hits=4.5k misses=312 ratio=93.5% pruned=0
+}
+
+func ExampleCache_ResetStats() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Get("admin")
+
+	fmt.Println("Hits before reset:", users.ResetStats().Hits)
+	users.Get("admin")
+	fmt.Println("Hits after reset:", users.Stats().Hits)
+	fmt.Println("Size:", users.Stats().Size)
+	// Output:
+	// Hits before reset: 1
+	// Hits after reset: 1
+	// Size: 1
+}
+
+func ExampleCache_StatsDelta() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Get("admin")
+	users.Get("admin")
+	fmt.Println("Hits this interval:", users.StatsDelta().Hits)
+
+	users.Get("admin")
+	fmt.Println("Hits this interval:", users.StatsDelta().Hits)
+
+	// Delta works with any two snapshots, even across a reset.
+	before := *users.Stats()
+	users.ResetStats()
+	users.Get("luser")
+	fmt.Println("Misses since snapshot:", users.Stats().Delta(before).Misses)
+	// Output:
+	// Hits this interval: 2
+	// Hits this interval: 1
+	// Misses since snapshot: 1
+}
+
+func ExampleCache_HotKeys() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	for user, gets := range map[string]int{"admin": 3, "luser": 1, "guest": 5, "nobody": 0} {
+		users.Save(user, user, cache.Options{})
+
+		for i := 0; i < gets; i++ {
+			users.Get(user)
+		}
+	}
+
+	for _, stat := range users.HotKeys(2) {
+		fmt.Println(stat.Key, stat.Hits)
+	}
+	// Output:
+	// guest 5
+	// admin 3
+}
+
+func ExampleCache_ColdKeys() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Save("luser", "Under Dawggy", cache.Options{})
+	users.Save("guest", "Just Visiting", cache.Options{})
+	users.Get("admin")
+
+	for _, stat := range users.ColdKeys(2) {
+		fmt.Println(stat.Key, stat.Hits)
+	}
+	// Output:
+	// guest 0
+	// luser 0
+}
+
+func ExampleCache_TopBy() {
+	clock := cachetest.NewClock()
+	users := cache.New(cache.Config{Clock: clock})
+	defer users.Stop(true)
+
+	for _, name := range []string{"admin", "luser", "guest"} {
+		users.Save(name, name, cache.Options{})
+		clock.Advance(time.Minute)
+	}
+
+	users.Get("admin")
+	users.Get("guest")
+
+	for _, field := range []cache.SortField{cache.SortHits, cache.SortTime, cache.SortLast} {
+		fmt.Println(users.TopBy(field, 1)[0].Key)
+	}
+	// Output:
+	// admin
+	// admin
+	// luser
+}
+
+func ExampleConfig_pruneBatch() {
+	// Check two keys each second; four unused keys take two seconds to prune.
+	sessions := cache.New(cache.Config{PruneInterval: time.Second, PruneBatch: 2, MaxUnused: time.Millisecond})
+	defer sessions.Stop(true)
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		sessions.Save(key, "unused", cache.Options{})
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	fmt.Println("Pruned:", sessions.Stats().Pruned, "Size:", sessions.Stats().Size)
+	// Output:
+	// Pruned: 2 Size: 2
+}
+
+func ExampleConfig_expiryOnly() {
+	// Items are never pruned for being unused, so the pruner only looks at items
+	// that expired, instead of checking every key in the cache.
+	sessions := cache.New(cache.Config{
+		PruneInterval: time.Minute,
+		PruneAfter:    cache.Forever,
+		MaxUnused:     cache.Forever,
+	})
+	defer sessions.Stop(true)
+
+	for i := 0; i < 1000; i++ {
+		sessions.Save(fmt.Sprint("user", i), i, cache.Options{})
+	}
+
+	sessions.Save("token", "abc", cache.Options{Expire: time.Now().Add(-time.Second)})
+	fmt.Println("Pruned:", sessions.Prune(), "Size:", sessions.Stats().Size)
+	// Output:
+	// Pruned: 1 Size: 1000
+}
+
+func ExampleConfig_memoryFraction() {
+	// A memory limit is usually set with GOMEMLIMIT. This sets a large one so a tiny
+	// fraction of it makes a budget of about 1KB for this example.
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(1 << 40))
+
+	users := cache.New(cache.Config{MemoryFraction: 1e-9})
+	defer users.Stop(true)
+
+	for i := 0; i < 10; i++ {
+		users.Save(fmt.Sprint("user", i), strings.Repeat("x", 100), cache.Options{})
+	}
+
+	// The least recently used items are evicted when the pruner runs.
+	fmt.Println("Evicted:", users.Prune(), "Keys:", users.Keys())
+	// Output:
+	// Evicted: 6 Keys: [user6 user7 user8 user9]
+}
+
+func ExampleOptions_priority() {
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(1 << 40))
+
+	// The fake clock does not move, so every item was last used at the same time.
+	users := cache.New(cache.Config{MemoryFraction: 1e-9, Clock: cachetest.NewClock()})
+	defer users.Stop(true)
+
+	for i := 0; i < 10; i++ {
+		users.Save(fmt.Sprint("user", i), strings.Repeat("x", 100), cache.Options{Priority: i % 3})
+	}
+
+	// Items with priority 0 are evicted first, then items with priority 1.
+	fmt.Println("Evicted:", users.Prune(), "Keys:", users.Keys())
+	// Output:
+	// Evicted: 6 Keys: [user2 user5 user7 user8]
+}
+
+func ExampleConfig_maxCost() {
+	clock := cachetest.NewClock()
+	images := cache.New(cache.Config{PruneInterval: time.Hour, MaxCost: 10, Clock: clock})
+	defer images.Stop(true)
+
+	images.Save("large", "...", cache.Options{Cost: 6})
+	clock.Advance(time.Second) // save each item at a different time.
+	images.Save("small", "...", cache.Options{Cost: 1})
+	clock.Advance(time.Second)
+	images.Save("medium", "...", cache.Options{Cost: 4})
+
+	// The least recently used items are evicted until the total cost (11) is not over 10.
+	fmt.Println("Evicted:", images.Prune(), "Keys:", images.Keys())
+	// Output:
+	// Evicted: 1 Keys: [medium small]
+}
+
+func ExampleConfig_tinyLFU() {
+	clock := cachetest.NewClock()
+	users := cache.New(cache.Config{PruneInterval: time.Hour, MaxCost: 3, TinyLFU: true, Clock: clock})
+	defer users.Stop(true)
+
+	for _, name := range []string{"admin", "dave", "luser"} {
+		users.Save(name, "popular", cache.Options{})
+
+		for i := 0; i < 5; i++ {
+			users.Get(name)
+		}
+	}
+
+	// A scan looks up a key once and saves it. It's the most recently used key,
+	// but it was requested less often than the least recently used key, so it's evicted.
+	clock.Advance(time.Second)
+
+	if users.Get("scanned") == nil {
+		users.Save("scanned", "once", cache.Options{})
+	}
+
+	fmt.Println("Evicted:", users.Prune(), "Keys:", users.Keys())
+	// Output:
+	// Evicted: 1 Keys: [admin dave luser]
+}
+
+func ExampleEvictionPolicy() {
+	queue := cache.New(cache.Config{PruneInterval: time.Hour, MaxCost: 2, Eviction: cache.NewFIFO()})
+	defer queue.Stop(true)
+
+	queue.Save("first", 1, cache.Options{})
+	queue.Save("second", 2, cache.Options{})
+	queue.Save("third", 3, cache.Options{})
+	queue.Get("first") // FIFO evicts the first saved key, even if it's used.
+
+	fmt.Println("Evicted:", queue.Prune(), "Keys:", queue.Keys())
+
+	counts := cache.New(cache.Config{PruneInterval: time.Hour, MaxCost: 2, Eviction: cache.NewLFU()})
+	defer counts.Stop(true)
+
+	counts.Save("first", 1, cache.Options{})
+	counts.Save("second", 2, cache.Options{})
+	counts.Save("third", 3, cache.Options{})
+	counts.Get("first")
+	counts.Get("third") // LFU evicts the key retrieved least often.
+
+	fmt.Println("Evicted:", counts.Prune(), "Keys:", counts.Keys())
+	// Output:
+	// Evicted: 1 Keys: [second third]
+	// Evicted: 1 Keys: [first third]
+}
+
+// auditStore is a Store that counts the changes to a MapStore.
+type auditStore struct {
+	cache.MapStore
+	sets, deletes int
+}
+
+func (a *auditStore) Set(key string, item *cache.Item) {
+	a.sets++
+	a.MapStore.Set(key, item)
+}
+
+func (a *auditStore) Delete(key string) {
+	a.deletes++
+	a.MapStore.Delete(key)
+}
+
+func ExampleConfig_loader() {
+	calls := 0
+	users := cache.New(cache.Config{
+		Loader: func(_ context.Context, key string) (any, cache.Options, error) {
+			calls++
+
+			switch key {
+			case "admin":
+				return "Super Dooper", cache.Options{TTL: time.Hour}, nil
+			case "broken":
+				return nil, cache.Options{}, errors.New("database is down")
+			default:
+				return nil, cache.Options{}, nil // not found.
+			}
+		},
+	})
+	defer users.Stop(true)
+
+	fmt.Println(users.Get("admin").Data, users.Get("admin").Data, users.Get("nobody"), calls)
+
+	_, err := users.Load(context.Background(), "broken")
+	fmt.Println(err)
+	// Output:
+	// Super Dooper Super Dooper <nil> 2
+	// loading broken: database is down
+}
+
+func TestLoaderSaveWhileLoading(t *testing.T) {
+	t.Parallel()
+
+	loading, release := make(chan struct{}), make(chan struct{})
+	users := cache.New(cache.Config{
+		Loader: func(context.Context, string) (any, cache.Options, error) {
+			close(loading)
+			<-release
+
+			return "Stale", cache.Options{}, nil
+		},
+	})
+	t.Cleanup(func() { users.Stop(true) })
+
+	go func() {
+		<-loading
+		users.Save("admin", "Fresh", cache.Options{})
+		close(release)
+	}()
+
+	item, err := users.Load(context.Background(), "admin")
+	if err != nil || item == nil || item.Data != "Fresh" {
+		t.Fatalf("Load returned %v, %v; expected the item saved while the Loader was running", item, err)
+	}
+
+	if data := users.Get("admin").Data; data != "Fresh" {
+		t.Errorf("the Loader overwrote the item saved while it was running with %v", data)
+	}
+}
+
+func ExampleConfig_missFilter() {
+	calls := 0
+	users := cache.New(cache.Config{
+		Loader: func(context.Context, string) (any, cache.Options, error) {
+			calls++
+			return nil, cache.Options{}, nil // nobody exists.
+		},
+		MissFilter: &cache.MissFilterConfig{Keys: 1000, Reset: time.Minute},
+	})
+	defer users.Stop(true)
+
+	for i := 0; i < 100; i++ {
+		users.Get("nobody")
+	}
+
+	fmt.Println("Loader calls:", calls, "Misses:", users.Stats().Misses)
+
+	users.Save("nobody", "Somebody", cache.Options{}) // saving a key removes it from the filter.
+	fmt.Println(users.Get("nobody").Data)
+	// Output:
+	// Loader calls: 1 Misses: 1
+	// Somebody
+}
+
+func ExampleConfig_writer() {
+	database := map[string]any{} // a real database is safe for concurrent use.
+	settings := cache.New(cache.Config{
+		Writer: func(_ context.Context, key string, item *cache.Item) error {
+			database[key] = item.Data
+			return nil
+		},
+		WriteAsync: true,
+	})
+
+	settings.Save("theme", "dark", cache.Options{})
+	settings.Save("theme", "light", cache.Options{})
+	_, _ = settings.Increment("visits", 1)
+	settings.Stop(true) // waits for the queued writes.
+
+	fmt.Println(database)
+	// Output:
+	// map[theme:light visits:1]
+}
+
+func ExampleServe() {
+	sidecar := cache.New(cache.Config{})
+	defer sidecar.Stop(true)
+
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer listener.Close()
+
+	go cache.Serve(listener, sidecar) //nolint:errcheck // returns when the listener closes.
+
+	client, err := cache.Dial("tcp", listener.Addr().String(), time.Second)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	client.Save("admin", "Super Dooper", cache.Options{})
+	fmt.Println("Updated:", client.Save("admin", "Super Duper", cache.Options{}))
+	fmt.Println("Data:", client.Get("admin").Data)
+	fmt.Println("Missing:", client.Get("guest") == nil, client.Err())
+	fmt.Println("Deleted:", client.Delete("admin"))
+	fmt.Println("Stats:", client.Stats().Hits, client.Stats().Misses)
+
+	client.Close()
+	fmt.Println("Closed:", client.Get("admin") == nil, client.Err())
+	// Output:
+	// Updated: true
+	// Data: Super Duper
+	// Missing: true <nil>
+	// Deleted: true
+	// Stats: 1 1
+	// Closed: true remote cache client is closed
+}
+
+// mapOverflow is an Overflow that stores entries in a map.
+type mapOverflow map[string]*cache.Entry
+
+func (m mapOverflow) Store(entry *cache.Entry) error        { m[entry.Key] = entry; return nil }
+func (m mapOverflow) Load(key string) (*cache.Entry, error) { return m[key], nil }
+func (m mapOverflow) Delete(key string) error               { delete(m, key); return nil }
+
+// TestOverflowWrites checks that keys written or removed while their item is stored
+// in the Overflow do not get the stored item back on a later Get.
+func TestOverflowWrites(t *testing.T) {
+	t.Parallel()
+
+	// spilled returns a cache with the admin key stored in the Overflow.
+	spilled := func(t *testing.T) *cache.Cache {
+		t.Helper()
+
+		clock := cachetest.NewClock()
+		users := cache.New(cache.Config{PruneInterval: time.Hour, MaxCost: 1, Overflow: mapOverflow{}, Clock: clock})
+		t.Cleanup(func() { users.Stop(true) })
+
+		users.Save("admin", "Super Dooper", cache.Options{})
+		clock.Advance(time.Second)
+		users.Save("guest", "Nobody", cache.Options{})
+
+		if users.Prune() != 1 || users.Keys()[0] != "guest" {
+			t.Fatal("admin was not evicted to the Overflow")
+		}
+
+		return users
+	}
+
+	t.Run("flush", func(t *testing.T) {
+		t.Parallel()
+
+		users := spilled(t)
+		users.Flush()
+
+		if item := users.Get("admin"); item != nil {
+			t.Errorf("flushed key came back from the Overflow: %v", item.Data)
+		}
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		t.Parallel()
+
+		users := spilled(t)
+		users.Save("luser", "Under Dawggy", cache.Options{})
+
+		if err := users.Rename("luser", "admin", false); !errors.Is(err, cache.ErrKeyExists) {
+			t.Errorf("expected ErrKeyExists renaming onto a key in the Overflow, got %v", err)
+		}
+
+		if err := users.Rename("luser", "admin", true); err != nil {
+			t.Fatal(err)
+		}
+
+		if item := users.Get("admin"); item == nil || item.Data != "Under Dawggy" {
+			t.Errorf("renamed item was replaced by the item in the Overflow: %v", item)
+		}
+	})
+
+	t.Run("import", func(t *testing.T) {
+		t.Parallel()
+
+		source := cache.New(cache.Config{})
+		t.Cleanup(func() { source.Stop(true) })
+		source.Save("admin", "Imported", cache.Options{})
+
+		var buf bytes.Buffer
+		if err := source.Export(&buf, cache.GobCodec{}); err != nil {
+			t.Fatal(err)
+		}
+
+		users := spilled(t)
+		if err := users.Import(&buf, cache.GobCodec{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if item := users.Get("admin"); item == nil || item.Data != "Imported" {
+			t.Errorf("imported item was replaced by the item in the Overflow: %v", item)
+		}
+	})
+}
+
+func ExampleStore() {
+	store := &auditStore{MapStore: cache.NewMapStore(0)}
+	users := cache.New(cache.Config{Store: store})
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Save("admin", "Super Duper", cache.Options{})
+	users.Save("guest", "Nobody", cache.Options{})
+	users.Delete("guest")
+	users.Stop(false) // the store is not used while the cache is stopped.
+
+	fmt.Println("Sets:", store.sets, "Deletes:", store.deletes, "Size:", store.Len())
+	// Output:
+	// Sets: 3 Deletes: 1 Size: 1
+}
+
+func ExampleConfig_evictionSamples() {
+	clock := cachetest.NewClock()
+	sessions := cache.New(cache.Config{PruneInterval: time.Hour, MaxCost: 2, EvictionSamples: 5, Clock: clock})
+	defer sessions.Stop(true)
+
+	for _, key := range []string{"oldest", "older", "newest"} {
+		sessions.Save(key, "session", cache.Options{})
+		clock.Advance(time.Second)
+	}
+
+	// This cache is smaller than the sample, so the least recently used item is always found.
+	fmt.Println("Evicted:", sessions.Prune(), "Keys:", sessions.Keys())
+	// Output:
+	// Evicted: 1 Keys: [newest older]
+}
+
+func ExampleLFU() {
+	lfu := cache.NewLFU()
+	lfu.DecayAfter = 10 // halve the counts after every 10 retrievals.
+
+	users := cache.New(cache.Config{PruneInterval: time.Hour, MaxCost: 1, Eviction: lfu})
+	defer users.Stop(true)
+
+	users.Save("old", "popular yesterday", cache.Options{})
+	users.Save("new", "popular today", cache.Options{})
+
+	for i := 0; i < 8; i++ {
+		users.Get("old")
+	}
+
+	// The 10th retrieval halves the counts: old 4, new 1. Then new reaches 5.
+	// Without decay, old would keep a count of 8, and new would be evicted.
+	for i := 0; i < 6; i++ {
+		users.Get("new")
+	}
+
+	fmt.Println("Evicted:", users.Prune(), "Keys:", users.Keys())
+	// Output:
+	// Evicted: 1 Keys: [new]
+}
+
+func TestLFUDecay(t *testing.T) {
+	t.Parallel()
+
+	lfu := cache.NewLFU()
+	lfu.DecayAfter = 5
+	lfu.OnSave("first")
+	lfu.OnSave("second")
+
+	// The 5th retrieval halves the counts from 3 and 2 to 1 and 1,
+	// so the key saved first is evicted first again.
+	for _, key := range []string{"first", "first", "first", "second", "second"} {
+		lfu.OnGet(key)
+	}
+
+	if victim, _ := lfu.Victim(); victim != "first" {
+		t.Errorf("expected the key saved first to be evicted after decay, got %q", victim)
+	}
+
+	lfu.OnDelete("first")
+
+	if victim, _ := lfu.Victim(); victim != "second" {
+		t.Errorf("expected second to be evicted next, got %q", victim)
+	}
+}
+
+func ExampleCache_Reconfigure() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+
+	// Prune items that go unused for 10 milliseconds, and update the time more often.
+	err := users.Reconfigure(cache.Config{MaxUnused: 10 * time.Millisecond, RequestAccuracy: 100 * time.Millisecond})
+	time.Sleep(250 * time.Millisecond)
+	fmt.Println("Error:", err, "Pruned:", users.Prune())
+
+	users.Stop(false)
+	fmt.Println("Stopped:", users.Reconfigure(cache.Config{}))
+	// Output:
+	// Error: <nil> Pruned: 1
+	// Stopped: cache is stopped
+}
+
+func ExampleCache_Prune() {
+	// The pruner routine is not enabled, so items are only removed by calling Prune.
+	sessions := cache.New(cache.Config{})
+	defer sessions.Stop(true)
+
+	sessions.Save("expired", "abc", cache.Options{Expire: time.Now().Add(-time.Minute)})
+	sessions.Save("current", "def", cache.Options{Expire: time.Now().Add(time.Hour)})
+
+	fmt.Println("Pruned:", sessions.Prune())
+	fmt.Println("Keys:", sessions.Keys())
+	// Output:
+	// Pruned: 1
+	// Keys: [current]
+}
+
+func ExampleCache_PruneFunc() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Save("luser", "Under Dawggy", cache.Options{})
+	users.Save("guest", "Guest User", cache.Options{})
+
+	// Remove every user that isn't an admin.
+	pruned := users.PruneFunc(func(key string, _ *cache.Item) bool {
+		return key != "admin"
+	})
+
+	fmt.Println("Pruned:", pruned, "Keys:", users.Keys(), "Stats:", users.Stats().PrunedFunc)
+	// Output:
+	// Pruned: 2 Keys: [admin] Stats: 2
+}
+
+func ExampleCache_GetData() {
+	pages := cache.New(cache.Config{})
+	defer pages.Stop(true)
+
+	pages.Save("/index.html", []byte("<html>...</html>"), cache.Options{})
+
+	if data, ok := pages.GetData("/index.html"); ok {
+		fmt.Println(string(data.([]byte))) //nolint:forcetypeassert
+	}
+
+	_, ok := pages.GetData("/missing.html")
+	fmt.Println("Found:", ok, "Hits:", pages.Stats().Hits, "Misses:", pages.Stats().Misses)
+	// Output:
+	// <html>...</html>
+	// Found: false Hits: 1 Misses: 1
+}
+
+func ExampleCache_Replace() {
+	sessions := cache.New(cache.Config{})
+	defer sessions.Stop(true)
+
+	fmt.Println("Replaced:", sessions.Replace("abc", "new", cache.Options{}))
+	sessions.Save("abc", "old", cache.Options{})
+	fmt.Println("Replaced:", sessions.Replace("abc", "new", cache.Options{}))
+	fmt.Println("Data:", sessions.Get("abc").Data)
+	// Output:
+	// Replaced: false
+	// Replaced: true
+	// Data: new
+}
+
+func ExampleCache_CompareAndSwap() {
+	states := cache.New(cache.Config{})
+	defer states.Stop(true)
+
+	fmt.Println("Nil:", states.CompareAndSwap("job", nil, nil, cache.Options{}))
+	fmt.Println("Created:", states.CompareAndSwap("job", nil, "queued", cache.Options{}))
+	fmt.Println("Swapped:", states.CompareAndSwap("job", "running", "done", cache.Options{}))
+	fmt.Println("Swapped:", states.CompareAndSwap("job", "queued", "running", cache.Options{}))
+	fmt.Println("State:", states.Get("job").Data)
+	// Output:
+	// Nil: false
+	// Created: true
+	// Swapped: false
+	// Swapped: true
+	// State: running
+}
+
+func ExampleCache_CompareAndDelete() {
+	locks := cache.New(cache.Config{})
+	defer locks.Stop(true)
+
+	locks.Save("lock", "owner-1", cache.Options{})
+
+	// Only the owner of the lock may release it.
+	fmt.Println("Released:", locks.CompareAndDelete("lock", "owner-2"))
+	fmt.Println("Released:", locks.CompareAndDelete("lock", "owner-1"))
+	fmt.Println("Released:", locks.CompareAndDelete("lock", "owner-1"))
+	// Output:
+	// Released: false
+	// Released: true
+	// Released: false
+}
+
+func ExampleCache_Increment() {
+	counters := cache.New(cache.Config{})
+	defer counters.Stop(true)
+
+	counters.Increment("requests", 1)
+	counters.Increment("requests", 5)
+	value, err := counters.Increment("requests", -2)
+	fmt.Println("Requests:", value, err)
+
+	counters.Save("name", "not a number", cache.Options{})
+	_, err = counters.Increment("name", 1)
+	fmt.Println("Error:", err)
+	// Output:
+	// Requests: 4 <nil>
+	// Error: cached data is not an integer
+}
+
+func ExampleCache_Append() {
+	logs := cache.New(cache.Config{})
+	defer logs.Stop(true)
+
+	logs.Append("log", []byte("one"))
+	saved := logs.Get("log").Data.([]byte)
+	fmt.Println("Error:", logs.Append("log", ",two"))
+	fmt.Println("Log:", string(logs.Get("log").Data.([]byte)))
+	fmt.Println("Saved:", string(saved)) // the previously returned slice is not modified.
+
+	logs.Save("number", 1, cache.Options{})
+	fmt.Println("Error:", logs.Append("number", "1"))
+	fmt.Println("Error:", logs.Append("log", 1))
+	// Output:
+	// Error: <nil>
+	// Log: one,two
+	// Saved: one
+	// Error: data is not a string or byte slice
+	// Error: data is not a string or byte slice
+}
+
+func ExampleCache_Rename() {
+	jobs := cache.New(cache.Config{})
+	defer jobs.Stop(true)
+
+	jobs.Save("pending", "job 1", cache.Options{})
+	jobs.Save("running", "job 0", cache.Options{})
+
+	fmt.Println("Error:", jobs.Rename("missing", "running", false))
+	fmt.Println("Error:", jobs.Rename("pending", "running", false))
+	fmt.Println("Error:", jobs.Rename("pending", "pending", false))
+	fmt.Println("Error:", jobs.Rename("pending", "running", true))
+	fmt.Println("Running:", jobs.Get("running").Data)
+	fmt.Println("Pending:", jobs.Get("pending"))
+	fmt.Println("Deletes:", jobs.Stats().Deletes)
+	// Output:
+	// Error: key not found
+	// Error: key already exists
+	// Error: <nil>
+	// Error: <nil>
+	// Running: job 1
+	// Pending: <nil>
+	// Deletes: 1
+}
+
+func ExampleCache_TTL() {
+	sessions := cache.New(cache.Config{})
+	defer sessions.Stop(true)
+
+	sessions.Save("abc", "session data", cache.Options{})
+	ttl, exists := sessions.TTL("abc")
+	fmt.Println("Forever:", ttl == cache.Forever, exists)
+
+	fmt.Println("Updated:", sessions.Expire("abc", time.Now().Add(time.Hour)))
+	ttl, _ = sessions.TTL("abc")
+	fmt.Println("TTL:", ttl.Round(time.Minute))
+
+	_, exists = sessions.TTL("missing")
+	fmt.Println("Exists:", exists)
+	// Output:
+	// Forever: true true
+	// Updated: true
+	// TTL: 1h0m0s
+	// Exists: false
+}
+
+func ExampleCache_Txn() {
+	accounts := cache.New(cache.Config{})
+	defer accounts.Stop(true)
+
+	accounts.Save("alice", 100, cache.Options{})
+	accounts.Save("bob", 20, cache.Options{})
+
+	// Move 30 from alice to bob. Nobody sees one balance change without the other.
+	err := accounts.Txn(func(tx *cache.Txn) error {
+		alice, _ := tx.Get("alice").Data.(int)
+		bob, _ := tx.Get("bob").Data.(int)
+		tx.Save("alice", alice-30, cache.Options{})
+		tx.Save("bob", bob+30, cache.Options{})
+
+		return nil
+	})
+
+	fmt.Println("Error:", err)
+	fmt.Println("Alice:", accounts.Get("alice").Data)
+	fmt.Println("Bob:", accounts.Get("bob").Data)
+	// Output:
+	// Error: <nil>
+	// Alice: 70
+	// Bob: 50
+}
+
+func TestTxnPanic(t *testing.T) {
+	t.Parallel()
+
+	accounts := cache.New(cache.Config{})
+	t.Cleanup(func() { accounts.Stop(true) })
+
+	accounts.Save("alice", 100, cache.Options{})
+
+	err := accounts.Txn(func(tx *cache.Txn) error {
+		tx.Save("alice", 0, cache.Options{})
+		panic("oops")
+	})
+	if !errors.Is(err, cache.ErrTxnPanic) {
+		t.Errorf("expected ErrTxnPanic from a transaction that panicked, got %v", err)
+	}
+
+	if data := accounts.Get("alice").Data; data != 100 {
+		t.Errorf("the changes from a transaction that panicked were committed: %v", data)
+	}
+}
+
+func ExampleCache_Compute() {
+	carts := cache.New(cache.Config{})
+	defer carts.Stop(true)
+
+	// Add an item to a cart, or remove the cart when it's empty.
+	update := func(add int) func(old *cache.Item) (any, cache.Options, bool) {
+		return func(old *cache.Item) (any, cache.Options, bool) {
+			count := add
+			if old != nil {
+				count += old.Data.(int)
+			}
+
+			return count, cache.Options{}, count > 0
+		}
+	}
+
+	carts.Compute("cart", update(2))
+	carts.Compute("cart", update(1))
+	fmt.Println("Items:", carts.Get("cart").Data)
+	carts.Compute("cart", update(-3))
+	fmt.Println("Cart:", carts.Get("cart"))
+	// Output:
+	// Items: 3
+	// Cart: <nil>
+}
+
+func ExampleCache_Shutdown() {
+	users := cache.New(cache.Config{QueueSize: 16})
+	defer users.Stop(true) // free the memory.
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Requests already sent are finished before the processor stops.
+	fmt.Println("Shutdown:", users.Shutdown(ctx))
+	// Requests after a shutdown return nil instead of panicking.
+	fmt.Println("After:", users.Get("admin"))
+	// Output:
+	// Shutdown: <nil>
+	// After: <nil>
+}
+
+func TestShutdownResults(t *testing.T) {
+	t.Parallel()
+
+	users := cache.New(cache.Config{})
+	t.Cleanup(func() { users.Stop(true) })
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+
+	if err := users.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// These read the results of processor functions, which never run after a shutdown.
+	if users.Flush() != 0 || users.Prune() != 0 || users.PruneFunc(func(string, *cache.Item) bool { return true }) != 0 {
+		t.Error("Flush, Prune and PruneFunc must return 0 after Shutdown")
+	}
+
+	if len(users.HotKeys(1)) != 0 || len(users.ColdKeys(1)) != 0 || len(users.TopBy(cache.SortTime, 1)) != 0 {
+		t.Error("HotKeys, ColdKeys and TopBy must return no keys after Shutdown")
+	}
+
+	if users.List() == nil || users.Stats() == nil || users.StatsDelta() == nil {
+		t.Error("List and Stats must not return nil after Shutdown")
+	}
+}
+
+func ExampleCache_StopAndExport() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Save("luser", "Under Dawggy", cache.Options{})
+
+	items := users.StopAndExport()
+	fmt.Println("Exported:", len(items), items["admin"].Data)
+	fmt.Println("Again:", users.StopAndExport())
+	// Output:
+	// Exported: 2 Super Dooper
+	// Again: map[]
+}
+
+func ExampleCache_StartWithOptions() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Get("admin")
+
+	// Restart with the same items, but new stats.
+	users.Stop(false)
+	users.StartWithOptions(context.Background(), cache.StartOptions{KeepItems: true})
+
+	stats := users.Stats()
+	fmt.Println("Size:", stats.Size, "Hits:", stats.Hits)
+	// Output:
+	// Size: 1 Hits: 0
+}
+
+func ExampleCache_Namespace() {
+	shared := cache.New(cache.Config{})
+	defer shared.Stop(true)
+
+	users := shared.Namespace("users")
+	groups := shared.Namespace("groups")
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	groups.Save("admin", []string{"admin", "luser"}, cache.Options{})
+	users.Get("admin")
+
+	fmt.Println("Users:", users.Get("admin").Data)
+	fmt.Println("Shared:", shared.Get("users:admin").Data)
+	fmt.Println("Flushed:", groups.Flush())
+	fmt.Println("User Hits:", users.Stats().Hits)
+	fmt.Println("Shared Size:", shared.Stats().Size)
+	// Output:
+	// Users: Super Dooper
+	// Shared: Super Dooper
+	// Flushed: 1
+	// User Hits: 2
+	// Shared Size: 1
+}
+
+func ExampleCache_SaveNegative() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	// The database said this user does not exist; remember that for a minute.
+	users.SaveNegative("ghost", nil, time.Minute)
+
+	item := users.Get("ghost")
+	fmt.Println("Negative:", item.Negative)
+	fmt.Println("Error:", item.Data)
+	fmt.Println("Not Found:", errors.Is(item.Data.(error), cache.ErrKeyNotFound))
+	// Output:
+	// Negative: true
+	// Error: key not found
+	// Not Found: true
+}
+
+func ExampleCache_Subscribe() {
+	items := cache.New(cache.Config{})
+	defer items.Stop(true)
+
+	events := items.Subscribe()
+	defer items.Unsubscribe(events)
+
+	items.Save("thing", 1, cache.Options{})
+	items.Save("thing", 2, cache.Options{})
+	items.Delete("thing")
+
+	for i := 0; i < 3; i++ {
+		event := <-events
+		fmt.Println(event.Reason, event.Key)
+	}
+	// Output:
+	// save thing
+	// update thing
+	// delete thing
+}
+
+func ExampleCache_Watch() {
+	items := cache.New(cache.Config{RequestAccuracy: 100 * time.Millisecond})
+	defer items.Stop(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watch := items.Watch(ctx, "thing")
+
+	items.Save("thing", 1, cache.Options{})
+	items.Save("other", 2, cache.Options{})
+	items.Delete("thing")
+	cancel() // the channel is closed within RequestAccuracy.
+
+	for item := range watch {
+		if item == nil {
+			fmt.Println("Deleted")
+		} else {
+			fmt.Println("Saved:", item.Data)
+		}
+	}
+
+	fmt.Println("Closed")
+	// Output:
+	// Saved: 1
+	// Deleted
+	// Closed
+}
+
+func ExampleRefresher() {
+	prices := cache.New(cache.Config{
+		PruneInterval:   time.Second,
+		RequestAccuracy: 100 * time.Millisecond,
+		Refresher: func(key string, old *cache.Item) (any, error) {
+			return old.Data.(int) + 1, nil // pretend this is a slow API call.
+		},
+	})
+	defer prices.Stop(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watch := prices.Watch(ctx, "price")
+
+	prices.Save("price", 10, cache.Options{RefreshAfter: 500 * time.Millisecond})
+	<-watch
+	time.Sleep(700 * time.Millisecond)
+	fmt.Println("Price:", prices.Get("price").Data) // still in use, so it gets refreshed.
+	fmt.Println("Refreshed:", (<-watch).Data)
+	fmt.Println("Refreshes:", prices.Stats().Refreshes)
+	// Output:
+	// Price: 10
+	// Refreshed: 11
+	// Refreshes: 1
+}
+
+func ExampleCache_Handler() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{Prune: true})
+	users.Get("admin")
+
+	for _, url := range []string{"/debug/cache", "/debug/cache?items=true"} {
+		rec := httptest.NewRecorder()
+		users.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+
+		var output struct {
+			Stats cache.Stats `json:"stats"`
+			Items []struct {
+				Key   string `json:"key"`
+				Hits  int64  `json:"hits"`
+				Prune bool   `json:"prune"`
+			} `json:"items"`
+		}
+
+		err := json.Unmarshal(rec.Body.Bytes(), &output)
+		fmt.Println(rec.Header().Get("Content-Type"), err, output.Stats.Hits, output.Items)
+	}
+	// Output:
+	// application/json <nil> 1 []
+	// application/json <nil> 1 [{admin 1 true}]
+}
+
+func ExampleMemoize() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	calls := 0
+	lookup := cache.Memoize(users, time.Minute, func(key string) (string, error) {
+		calls++ // pretend this is a slow database query.
+		return "name of " + key, nil
+	})
+
+	name, _ := lookup("user1")
+	name, _ = lookup("user1")
+	fmt.Println(name)
+	fmt.Println("Calls:", calls)
+	// Output:
+	// name of user1
+	// Calls: 1
+}
+
+func ExampleItem_Expired() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("session", "abc", cache.Options{Expire: time.Now().Add(-time.Second)})
+	users.Save("user", "dave", cache.Options{Prune: true})
+
+	session := users.List()["session"] // List returns expired items until they're removed.
+	user := users.Get("user")
+
+	fmt.Println(session.Expired(), user.Expired(), user.Expires.IsZero())
+	fmt.Println(user.Age() < time.Minute, user.Idle() < time.Minute, user.Prunable, session.Prunable)
+	// Output:
+	// true false true
+	// true true true false
+}
+
+func ExampleOptions_expire() {
+	clock := cachetest.NewClock()
+	sessions := cache.New(cache.Config{Clock: clock}) // the pruner is not running.
+	defer sessions.Stop(true)
+
+	sessions.Save("token", "abc", cache.Options{Expire: clock.Now().Add(time.Minute)})
+	fmt.Println(sessions.Get("token").Data)
+
+	clock.Advance(2 * time.Minute) // The expired item is removed when it's retrieved.
+	fmt.Println(sessions.Get("token"), sessions.Stats().Expired, sessions.Keys())
+	// Output:
+	// abc
+	// <nil> 1 []
+}
+
+func ExampleConfig_defaultOptions() {
+	clock := cachetest.NewClock()
+	sessions := cache.New(cache.Config{
+		Clock:          clock,
+		DefaultOptions: cache.Options{TTL: time.Hour, Prune: true},
+	})
+	defer sessions.Stop(true)
+
+	sessions.Save("user1", "token1", cache.Options{}) // uses the defaults.
+	sessions.Save("admin", "token2", cache.Options{Expire: clock.Now().Add(time.Minute)})
+
+	user, admin := sessions.Get("user1"), sessions.Get("admin")
+	fmt.Println(user.Prunable, user.Expires.Sub(clock.Now()))
+	fmt.Println(admin.Prunable, admin.Expires.Sub(clock.Now()))
+	// Output:
+	// true 1h0m0s
+	// false 1m0s
+}
+
+func ExampleCache_Export() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{Prune: true, Meta: map[string]string{"origin": "ldap"}})
+	users.Get("admin")
+
+	var buf bytes.Buffer
+	if err := users.Export(&buf, cache.GobCodec{}); err != nil {
+		panic(err)
+	}
+
+	// Import the items into another cache, like after a restart.
+	restored := cache.New(cache.Config{})
+	defer restored.Stop(true)
+
+	err := restored.Import(&buf, cache.GobCodec{})
+	admin := restored.Get("admin")
+
+	fmt.Println(err, admin.Data, admin.Hits, admin.Prunable, admin.Meta)
+	// Output:
+	// <nil> Super Dooper 2 true map[origin:ldap]
+}
+
+func ExampleConfig_journal() {
+	dir, err := os.MkdirTemp("", "cache")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := cache.Config{Journal: &cache.JournalConfig{Path: filepath.Join(dir, "users.journal")}}
+
+	users := cache.New(conf)
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Save("guest", "Nobody", cache.Options{})
+	users.Delete("guest")
+	users.Stop(true) // or crash.
+
+	// The journal is replayed when a new cache starts.
+	restored := cache.New(conf)
+	defer restored.Stop(true)
+
+	fmt.Println(restored.Get("admin").Data, restored.Get("guest"))
+	// Output:
+	// Super Dooper <nil>
+}
+
+func ExampleConfig_snapshot() {
+	dir, err := os.MkdirTemp("", "cache")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := cache.Config{
+		SnapshotPath:     filepath.Join(dir, "users.json"),
+		SnapshotInterval: 5 * time.Minute,
+		SnapshotOptions:  cache.ExportOptions{Codec: cache.JSONCodec{}},
+	}
+
+	users := cache.New(conf)
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Stop(true) // writes the final snapshot.
+
+	// The snapshot is loaded when a new cache starts.
+	restored := cache.New(conf)
+	defer restored.Stop(true)
+
+	fmt.Println(restored.Get("admin").Data)
+	// Output:
+	// Super Dooper
+}
+
+func ExampleCache_ExportWithOptions() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	for i := 0; i < 100; i++ {
+		users.Save(fmt.Sprint("user", i), `{"name":"repetitive JSON","active":true}`, cache.Options{})
+	}
+
+	opts := cache.ExportOptions{Codec: cache.JSONCodec{}, Compression: cache.Gzip{Level: gzip.BestSpeed}}
+
+	var plain, compressed bytes.Buffer
+	_ = users.Export(&plain, cache.JSONCodec{})
+	_ = users.ExportWithOptions(&compressed, opts)
+
+	restored := cache.New(cache.Config{})
+	defer restored.Stop(true)
+
+	err := restored.ImportWithOptions(&compressed, opts)
+
+	fmt.Println("Smaller:", compressed.Len()*5 < plain.Len())
+	fmt.Println("Imported:", restored.Stats().Size, err)
+	// Output:
+	// Smaller: true
+	// Imported: 100 <nil>
+}
+
+func ExampleJSONCodec() {
+	clock := cachetest.NewClock()
+	users := cache.New(cache.Config{Clock: clock})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{TTL: time.Hour, Meta: map[string]string{"origin": "ldap"}})
+
+	if err := users.Export(os.Stdout, cache.JSONCodec{Indent: "  "}); err != nil {
+		panic(err)
+	}
+	// Output:
+	// [
+	//   {
+	//     "key": "admin",
+	//     "data": "Super Dooper",
+	//     "created": "2020-01-01T00:00:00Z",
+	//     "lastAccess": "2020-01-01T00:00:00Z",
+	//     "hits": 0,
+	//     "version": 1,
+	//     "options": {
+	//       "expire": "2020-01-01T01:00:00Z",
+	//       "ttl": 3600000000000,
+	//       "meta": {
+	//         "origin": "ldap"
+	//       }
+	//     }
+	//   }
+	// ]
+}
+
+func ExampleOptions_meta() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("user1", "dave", cache.Options{Meta: map[string]string{"origin": "ldap", "version": "2"}})
+
+	item := users.Get("user1")
+	item.Meta["version"] = "3" // changing the copy does not change the cached item.
+
+	fmt.Println(item.Data, users.Get("user1").Meta)
+	// Output:
+	// dave map[origin:ldap version:2]
+}
+
+func ExampleCache_SaveIfVersion() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	fmt.Println(users.SaveIfVersion("user1", "dave", 0, cache.Options{})) // new key.
+
+	first := users.Get("user1")
+	second := users.Get("user1")
+
+	// Both callers change the item; the second save is rejected because the item changed.
+	fmt.Println(users.SaveIfVersion("user1", "david", first.Version, cache.Options{}))
+	fmt.Println(users.SaveIfVersion("user1", "dave2", second.Version, cache.Options{}))
+	fmt.Println(users.Get("user1").Data, users.Get("user1").Version > second.Version)
+	// Output:
+	// true
+	// true
+	// false
+	// david true
+}
+
+func ExampleCache_Once() {
+	clock := cachetest.NewClock()
+	alerts := cache.New(cache.Config{Clock: clock})
+	defer alerts.Stop(true)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ { // the same alert from 10 go routines.
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if alerts.Once("disk full", time.Hour) {
+				_, _ = alerts.Increment("fired", 1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	fired, _ := alerts.Increment("fired", 0)
+	fmt.Println("Fired:", fired)
+
+	clock.Advance(time.Hour + time.Second)
+	fmt.Println("After the window:", alerts.Once("disk full", time.Hour))
+	// Output:
+	// Fired: 1
+	// After the window: true
+}
+
+func TestOnceZeroWindow(t *testing.T) {
+	t.Parallel()
+
+	// A zero window must not fall back to the DefaultOptions TTL.
+	alerts := cache.New(cache.Config{DefaultOptions: cache.Options{TTL: time.Hour}})
+	t.Cleanup(func() { alerts.Stop(true) })
+
+	if !alerts.Once("disk full", 0) || !alerts.Once("disk full", 0) || !alerts.Once("disk full", -time.Second) {
+		t.Error("Once deduplicated a key with a window of 0 or less")
+	}
+
+	if alerts.Get("disk full") != nil {
+		t.Error("Once saved a key with a window of 0")
+	}
+}
+
+func ExampleCache_GetByIndex() {
+	type session struct{ UserID, Browser string }
+
+	sessions := cache.New(cache.Config{
+		Indexes: map[string]func(string, *cache.Item) string{
+			"user": func(_ string, item *cache.Item) string {
+				if s, ok := item.Data.(session); ok {
+					return s.UserID
+				}
+
+				return "" // not a session, not indexed.
+			},
+		},
+	})
+	defer sessions.Stop(true)
+
+	sessions.Save("s1", session{UserID: "alice", Browser: "firefox"}, cache.Options{})
+	sessions.Save("s2", session{UserID: "bob", Browser: "chrome"}, cache.Options{})
+	sessions.Save("s3", session{UserID: "alice", Browser: "safari"}, cache.Options{})
+
+	for _, item := range sessions.GetByIndex("user", "alice") {
+		fmt.Println(item.Data.(session).Browser)
+	}
+
+	sessions.Delete("s1")
+	sessions.Save("s2", session{UserID: "alice", Browser: "chrome"}, cache.Options{})
+	fmt.Println(len(sessions.GetByIndex("user", "alice")), len(sessions.GetByIndex("user", "bob")))
+	// Output:
+	// firefox
+	// safari
+	// 2 0
+}
+
+func ExampleCache_Stream() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	for _, name := range []string{"eve", "bob", "dan", "amy", "cal"} {
+		users.Save(name, len(name), cache.Options{})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // stops the stream if the loop returns early.
+
+	for item := range users.Stream(ctx) {
+		fmt.Println(item.Key, item.Item.Data)
+	}
+	// Output:
+	// amy 3
+	// bob 3
+	// cal 3
+	// dan 3
+	// eve 3
+}
+
+func ExampleCache_ForEach() {
+	sizes := cache.New(cache.Config{})
+	defer sizes.Stop(true)
+
+	for _, name := range []string{"tiny", "small", "medium", "large", "huge"} {
+		sizes.Save(name, len(name), cache.Options{})
+	}
+
+	total := 0
+
+	sizes.ForEach(func(_ string, item cache.Item) bool {
+		length, _ := item.Data.(int)
+		total += length
+
+		return true // false stops early.
+	})
+
+	fmt.Println("Total length:", total)
+	// Output: Total length: 24
+}
+
+func ExampleCache_KeysWithPrefix() {
+	pages := cache.New(cache.Config{})
+	defer pages.Stop(true)
+
+	for _, path := range []string{"/blog/2024/hello", "/blog/2025/again", "/about", "/blog/2025/more"} {
+		pages.Save(path, "<html>", cache.Options{})
+	}
+
+	fmt.Println(pages.KeysWithPrefix("/blog/2025/"))
+	fmt.Println(len(pages.ListPrefix("/blog/")))
+	// Output:
+	// [/blog/2025/again /blog/2025/more]
+	// 3
+}
+
+func ExampleCache_Search() {
+	avatars := cache.New(cache.Config{})
+	defer avatars.Stop(true)
+
+	for _, key := range []string{"avatar:alice:64", "avatar:alice:128", "avatar:bob:64", "profile:alice"} {
+		avatars.Save(key, []byte{}, cache.Options{})
+	}
+
+	fmt.Println(avatars.Search("avatar:alice:*"))
+	fmt.Println(avatars.Search("*:64"))
+	fmt.Println(avatars.SearchRegexp(regexp.MustCompile(`^avatar:\w+:1\d\d$`)))
+	// Output:
+	// [avatar:alice:128 avatar:alice:64]
+	// [avatar:alice:64 avatar:bob:64]
+	// [avatar:alice:128]
+}
+
+func ExampleCache_ListWhere() {
+	sizes := cache.New(cache.Config{})
+	defer sizes.Stop(true)
+
+	for _, name := range []string{"tiny", "small", "medium", "large", "huge"} {
+		sizes.Save(name, len(name), cache.Options{})
+	}
+
+	long := sizes.ListWhere(func(_ string, item *cache.Item) bool {
+		length, _ := item.Data.(int)
+		return length > 4
+	})
+
+	fmt.Println(len(long), long["medium"].Data)
+	// Output: 3 6
+}
+
+func ExampleCache_ListAfter() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	for _, name := range []string{"eve", "bob", "dan", "amy", "cal"} {
+		users.Save(name, len(name), cache.Options{})
+	}
+
+	for page, next := 1, ""; page == 1 || next != ""; page++ {
+		var items map[string]*cache.Item
+
+		items, next = users.ListAfter(next, 2)
+		fmt.Println("Page", page, len(items), "items, next:", next)
+	}
+	// Output:
+	// Page 1 2 items, next: bob
+	// Page 2 2 items, next: dan
+	// Page 3 1 items, next:
+}
+
+func ExampleOptions_pruneAfter() {
+	clock := cachetest.NewClock()
+	users := cache.New(cache.Config{PruneAfter: time.Hour, Clock: clock})
+	defer users.Stop(true)
+
+	users.Save("token", "abc", cache.Options{PruneAfter: time.Minute})
+	users.Save("user1", "dave", cache.Options{Prune: true})
+
+	fmt.Println("Pruned:", cachetest.PruneAfter(clock, users, 2*time.Minute), users.Keys())
+	// Output:
+	// Pruned: 1 [user1]
+}
+
+func ExampleOptions_maxUnused() {
+	clock := cachetest.NewClock()
+	settings := cache.New(cache.Config{MaxUnused: time.Hour, Clock: clock})
+	defer settings.Stop(true)
+
+	settings.Save("country codes", []string{"US", "CA"}, cache.Options{MaxUnused: 7 * 24 * time.Hour})
+	settings.Save("request id", "abc", cache.Options{})
+
+	fmt.Println("Pruned:", cachetest.PruneAfter(clock, settings, 2*time.Hour), settings.Keys())
+	// Output:
+	// Pruned: 1 [country codes]
+}
+
+func ExampleCache_Pin() {
+	clock := cachetest.NewClock()
+	settings := cache.New(cache.Config{MaxUnused: time.Hour, Clock: clock})
+	defer settings.Stop(true)
+
+	settings.Save("config", "critical", cache.Options{Pin: true})
+	settings.Save("request id", "abc", cache.Options{})
+
+	fmt.Println("Pruned:", cachetest.PruneAfter(clock, settings, 2*time.Hour), settings.Keys())
+	fmt.Println("Pinned:", settings.Get("config").Pinned)
+
+	settings.Pin("config", false)
+	fmt.Println("Pruned:", cachetest.PruneAfter(clock, settings, 2*time.Hour), settings.Keys())
+	// Output:
+	// Pruned: 1 [config]
+	// Pinned: true
+	// Pruned: 1 []
+}
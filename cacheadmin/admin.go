@@ -0,0 +1,170 @@
+// Package cacheadmin provides an HTTP API to inspect and modify a golift.io/cache Cache.
+// Operators can get, put and delete keys, and flush the cache, without a redeploy.
+//
+// Routes, relative to where the handler is mounted (use http.StripPrefix):
+//
+//	GET    /stats       - cache statistics.
+//	GET    /keys        - list of keys, without values.
+//	GET    /keys/{key}  - a cached item, with its value.
+//	PUT    /keys/{key}  - save the request body. JSON bodies are decoded, and may not be null. Query: ttl=5m, prune=true
+//	DELETE /keys/{key}  - delete a key.
+//	POST   /flush       - delete every key.
+//	POST   /prune       - run the pruner now.
+package cacheadmin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golift.io/cache"
+)
+
+// maxBody is the largest value that may be saved with a PUT request.
+const maxBody = 10 << 20
+
+// Authorizer returns true if a request is allowed to use the admin API.
+// Write a response and return false to reject the request.
+type Authorizer func(resp http.ResponseWriter, req *http.Request) bool
+
+// Admin is the cache admin API http.Handler.
+type Admin struct {
+	cache *cache.Cache
+	auth  Authorizer
+}
+
+// New returns the cache admin API. Every request is passed to auth before it's served.
+// The admin API can read and change every value in the cache, so auth may not be nil.
+func New(cache *cache.Cache, auth Authorizer) *Admin {
+	if auth == nil {
+		panic("cacheadmin: nil Authorizer")
+	}
+
+	return &Admin{cache: cache, auth: auth}
+}
+
+// BasicAuth returns an Authorizer that requires HTTP basic authentication.
+func BasicAuth(username, password string) Authorizer {
+	return func(resp http.ResponseWriter, req *http.Request) bool {
+		if user, pass, ok := req.BasicAuth(); ok &&
+			subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1 {
+			return true
+		}
+
+		resp.Header().Set("WWW-Authenticate", `Basic realm="cache admin"`)
+		http.Error(resp, "unauthorized", http.StatusUnauthorized)
+
+		return false
+	}
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (a *Admin) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if !a.auth(resp, req) {
+		return
+	}
+
+	path := "/" + strings.TrimPrefix(req.URL.Path, "/")
+
+	switch key, isKey := strings.CutPrefix(path, "/keys/"); {
+	case path == "/stats" && req.Method == http.MethodGet:
+		reply(resp, http.StatusOK, a.cache.Stats())
+	case path == "/keys" && req.Method == http.MethodGet:
+		reply(resp, http.StatusOK, a.cache.Keys())
+	case path == "/flush" && req.Method == http.MethodPost:
+		reply(resp, http.StatusOK, map[string]int{"flushed": a.cache.Flush()})
+	case path == "/prune" && req.Method == http.MethodPost:
+		reply(resp, http.StatusOK, map[string]int{"pruned": a.cache.Prune()})
+	case isKey && key != "" && req.Method == http.MethodGet:
+		a.get(resp, key)
+	case isKey && key != "" && req.Method == http.MethodPut:
+		a.put(resp, req, key)
+	case isKey && key != "" && req.Method == http.MethodDelete:
+		reply(resp, http.StatusOK, map[string]bool{"deleted": a.cache.Delete(key)})
+	case path == "/stats", path == "/keys", path == "/flush", path == "/prune", isKey && key != "":
+		http.Error(resp, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(resp, req)
+	}
+}
+
+func (a *Admin) get(resp http.ResponseWriter, key string) {
+	item := a.cache.Get(key)
+	if item == nil {
+		reply(resp, http.StatusNotFound, map[string]string{"error": cache.ErrKeyNotFound.Error()})
+		return
+	}
+
+	reply(resp, http.StatusOK, item)
+}
+
+func (a *Admin) put(resp http.ResponseWriter, req *http.Request, key string) {
+	opts, err := options(req)
+	if err != nil {
+		reply(resp, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(resp, req.Body, maxBody))
+	if err != nil {
+		reply(resp, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	var data any = string(body)
+
+	if media, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); media == "application/json" {
+		if err := json.Unmarshal(body, &data); err != nil {
+			reply(resp, http.StatusBadRequest, map[string]string{"error": "decoding json: " + err.Error()})
+			return
+		}
+
+		// Saving nil data deletes a key; use DELETE for that.
+		if data == nil {
+			reply(resp, http.StatusBadRequest, map[string]string{"error": "json body may not be null"})
+			return
+		}
+	}
+
+	reply(resp, http.StatusOK, map[string]bool{"updated": a.cache.Save(key, data, opts)})
+}
+
+// options reads the cache options from a PUT request's query parameters.
+func options(req *http.Request) (cache.Options, error) {
+	var (
+		opts  cache.Options
+		query = req.URL.Query()
+	)
+
+	if ttl := query.Get("ttl"); ttl != "" {
+		dur, err := time.ParseDuration(ttl)
+		if err != nil {
+			return opts, fmt.Errorf("invalid ttl: %w", err)
+		}
+
+		opts.Expire = time.Now().Add(dur)
+	}
+
+	if prune := query.Get("prune"); prune != "" {
+		var err error
+		if opts.Prune, err = strconv.ParseBool(prune); err != nil {
+			return opts, fmt.Errorf("invalid prune: %w", err)
+		}
+	}
+
+	return opts, nil
+}
+
+// reply writes a JSON response.
+func reply(resp http.ResponseWriter, status int, data any) {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+	_ = json.NewEncoder(resp).Encode(data)
+}
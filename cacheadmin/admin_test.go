@@ -0,0 +1,159 @@
+package cacheadmin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golift.io/cache"
+	"golift.io/cache/cacheadmin"
+)
+
+// serve sends a request with valid credentials to the admin API and returns the response.
+func serve(admin http.Handler, method, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.SetBasicAuth("admin", "secret")
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	rec := httptest.NewRecorder()
+	admin.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	items := cache.New(cache.Config{})
+	defer items.Stop(true)
+
+	admin := cacheadmin.New(items, cacheadmin.BasicAuth("admin", "secret"))
+
+	for _, setAuth := range []func(*http.Request){
+		func(*http.Request) {},
+		func(req *http.Request) { req.SetBasicAuth("admin", "wrong") },
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		setAuth(req)
+
+		rec := httptest.NewRecorder()
+		admin.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("wrong response without valid credentials: %d %v", rec.Code, rec.Header())
+		}
+	}
+
+	if rec := serve(admin, http.MethodGet, "/stats", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("wrong status with valid credentials: %d", rec.Code)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	t.Parallel()
+
+	items := cache.New(cache.Config{})
+	defer items.Stop(true)
+
+	admin := cacheadmin.New(items, cacheadmin.BasicAuth("admin", "secret"))
+
+	rec := serve(admin, http.MethodPut, "/keys/user?ttl=5m&prune=true", "application/json", `{"name":"admin"}`)
+	if rec.Code != http.StatusOK || rec.Body.String() != "{\"updated\":false}\n" {
+		t.Errorf("wrong PUT response: %d %s", rec.Code, rec.Body.String())
+	}
+
+	item := items.Get("user")
+	if data, _ := item.Data.(map[string]any); data["name"] != "admin" {
+		t.Errorf("JSON body was not decoded: %#v", item.Data)
+	}
+
+	if rec = serve(admin, http.MethodGet, "/keys/user", "", ""); !strings.Contains(rec.Body.String(), `"data":{"name":"admin"}`) {
+		t.Errorf("wrong GET response: %d %s", rec.Code, rec.Body.String())
+	}
+
+	serve(admin, http.MethodPut, "/keys/text", "text/plain", "hello")
+
+	if rec = serve(admin, http.MethodGet, "/keys", "", ""); rec.Body.String() != "[\"text\",\"user\"]\n" {
+		t.Errorf("wrong key list: %s", rec.Body.String())
+	}
+
+	if rec = serve(admin, http.MethodDelete, "/keys/text", "", ""); rec.Body.String() != "{\"deleted\":true}\n" {
+		t.Errorf("wrong DELETE response: %s", rec.Body.String())
+	}
+
+	if rec = serve(admin, http.MethodGet, "/keys/text", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("deleted key was found: %d", rec.Code)
+	}
+
+	if rec = serve(admin, http.MethodPut, "/keys/bad?ttl=soon", "", "x"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid ttl was accepted: %d", rec.Code)
+	}
+
+	if rec = serve(admin, http.MethodPut, "/keys/user", "application/json", "null"); rec.Code != http.StatusBadRequest {
+		t.Errorf("null JSON body was accepted: %d", rec.Code)
+	}
+
+	if items.Get("user") == nil {
+		t.Error("null JSON body deleted the key")
+	}
+}
+
+func TestTTL(t *testing.T) {
+	t.Parallel()
+
+	items := cache.New(cache.Config{RequestAccuracy: 100 * time.Millisecond})
+	defer items.Stop(true)
+
+	admin := cacheadmin.New(items, cacheadmin.BasicAuth("admin", "secret"))
+	serve(admin, http.MethodPut, "/keys/short?ttl=1ms", "", "gone soon")
+	serve(admin, http.MethodPut, "/keys/long?ttl=1h", "", "still here")
+	time.Sleep(200 * time.Millisecond)
+
+	if rec := serve(admin, http.MethodPost, "/prune", "", ""); rec.Body.String() != "{\"pruned\":1}\n" {
+		t.Errorf("wrong prune response: %s", rec.Body.String())
+	}
+
+	if rec := serve(admin, http.MethodGet, "/keys", "", ""); rec.Body.String() != "[\"long\"]\n" {
+		t.Errorf("wrong keys after prune: %s", rec.Body.String())
+	}
+
+	if rec := serve(admin, http.MethodPost, "/flush", "", ""); rec.Body.String() != "{\"flushed\":1}\n" {
+		t.Errorf("wrong flush response: %s", rec.Body.String())
+	}
+
+	if size := items.Stats().Size; size != 0 {
+		t.Errorf("cache not flushed, size: %d", size)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	t.Parallel()
+
+	items := cache.New(cache.Config{})
+	defer items.Stop(true)
+
+	admin := cacheadmin.New(items, cacheadmin.BasicAuth("admin", "secret"))
+
+	for _, test := range []struct {
+		method string
+		target string
+		code   int
+	}{
+		{http.MethodPost, "/stats", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/keys", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/flush", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/prune", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/keys/user", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/keys/", http.StatusNotFound},
+		{http.MethodGet, "/nothing", http.StatusNotFound},
+	} {
+		if rec := serve(admin, test.method, test.target, "", ""); rec.Code != test.code {
+			t.Errorf("%s %s: wrong status %d, expected %d", test.method, test.target, rec.Code, test.code)
+		}
+	}
+}
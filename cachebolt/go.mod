@@ -0,0 +1,12 @@
+module golift.io/cache/cachebolt
+
+go 1.23
+
+require (
+	go.etcd.io/bbolt v1.4.3
+	golift.io/cache v0.0.0
+)
+
+require golang.org/x/sys v0.29.0 // indirect
+
+replace golift.io/cache => ../
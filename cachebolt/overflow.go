@@ -0,0 +1,138 @@
+// Package cachebolt provides a cache.Overflow that stores evicted items in a bbolt file,
+// so a cache can hold more items than fit in memory. A Get for an item on disk is slower
+// than one in memory, but usually far faster than rebuilding the item.
+//
+// This package is its own Go module, so the cache module does not depend on bbolt.
+package cachebolt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	bolt "go.etcd.io/bbolt"
+	"golift.io/cache"
+)
+
+// Overflow is a cache.Overflow backed by a bbolt database. Create one with Open,
+// set it as cache.Config.Overflow, and Close it after the cache stops.
+// Writes are not synced to disk; the cache does not use items stored by a previous
+// process, so there is nothing to keep after a crash.
+type Overflow struct {
+	db     *bolt.DB
+	codec  cache.Codec
+	buffer bytes.Buffer // reused to encode items.
+}
+
+// bucket holds the stored items.
+var bucket = []byte("overflow") //nolint:gochecknoglobals
+
+// Make sure Overflow satisfies the interface.
+var _ cache.Overflow = (*Overflow)(nil)
+
+// Open creates or opens the database file at path, and removes the items stored in it.
+// Items are encoded with codec; nil uses cache.GobCodec, which requires gob.Register()
+// for every type of data the cache holds. Use one Overflow per cache.
+func Open(path string, codec cache.Codec) (*Overflow, error) {
+	if codec == nil {
+		codec = cache.GobCodec{}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{NoSync: true, NoFreelistSync: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening overflow database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucket) != nil {
+			if err := tx.DeleteBucket(bucket); err != nil {
+				return err //nolint:wrapcheck // wrapped below.
+			}
+		}
+
+		_, err := tx.CreateBucket(bucket)
+
+		return err //nolint:wrapcheck // wrapped below.
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("emptying overflow database: %w", err)
+	}
+
+	return &Overflow{db: db, codec: codec}, nil
+}
+
+// Store saves an evicted item.
+func (o *Overflow) Store(entry *cache.Entry) error {
+	o.buffer.Reset()
+
+	if err := o.codec.Encode(&o.buffer, entry); err != nil {
+		return fmt.Errorf("encoding item: %w", err)
+	}
+
+	err := o.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(entry.Key), o.buffer.Bytes())
+	})
+	if err != nil {
+		return fmt.Errorf("storing item: %w", err)
+	}
+
+	return nil
+}
+
+// Load returns the item stored with key, or nil if there is none.
+func (o *Overflow) Load(key string) (*cache.Entry, error) {
+	var data []byte
+
+	_ = o.db.View(func(tx *bolt.Tx) error {
+		data = bytes.Clone(tx.Bucket(bucket).Get([]byte(key))) // only valid in the transaction.
+		return nil
+	})
+
+	if data == nil {
+		return nil, nil //nolint:nilnil // the interface says so.
+	}
+
+	var entry cache.Entry
+	if err := o.codec.Decode(bytes.NewReader(data), &entry); err != nil {
+		return nil, fmt.Errorf("decoding item: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// Delete removes the item stored with key.
+func (o *Overflow) Delete(key string) error {
+	err := o.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("deleting item: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the database. Call this after the cache stops.
+func (o *Overflow) Close() error {
+	if err := o.db.Close(); err != nil {
+		return fmt.Errorf("closing overflow database: %w", err)
+	}
+
+	return nil
+}
+
+// Remove closes the database and deletes its file.
+func (o *Overflow) Remove() error {
+	path := o.db.Path()
+
+	if err := o.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("removing overflow database: %w", err)
+	}
+
+	return nil
+}
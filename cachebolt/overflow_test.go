@@ -0,0 +1,48 @@
+package cachebolt_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golift.io/cache"
+	"golift.io/cache/cachebolt"
+	"golift.io/cache/cachetest"
+)
+
+func ExampleOpen() {
+	dir, err := os.MkdirTemp("", "cachebolt")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	overflow, err := cachebolt.Open(filepath.Join(dir, "overflow.db"), nil)
+	if err != nil {
+		panic(err)
+	}
+	defer overflow.Close()
+
+	clock := cachetest.NewClock()
+	users := cache.New(cache.Config{PruneInterval: time.Hour, MaxCost: 1, Overflow: overflow, Clock: clock})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	clock.Advance(time.Second)
+	users.Save("guest", "Nobody", cache.Options{})
+
+	// The least recently used item is evicted to disk, and moved back when it's retrieved.
+	fmt.Println("Evicted:", users.Prune(), "Keys:", users.Keys())
+	clock.Advance(time.Second)
+	fmt.Println(users.Get("admin").Data, users.Get("admin").Hits)
+
+	// Deleting an item on disk deletes it from disk.
+	fmt.Println("Evicted:", users.Prune(), "Keys:", users.Keys())
+	fmt.Println(users.Delete("guest"), users.Get("guest"))
+	// Output:
+	// Evicted: 1 Keys: [guest]
+	// Super Dooper 2
+	// Evicted: 1 Keys: [admin]
+	// true <nil>
+}
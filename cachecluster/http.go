@@ -0,0 +1,82 @@
+package cachecluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrPeerStatus is returned by HTTPPeer when a node responds with an unexpected status.
+var ErrPeerStatus = errors.New("unexpected response from peer")
+
+// ServeHTTP serves the keys requested by HTTPPeers on other nodes, with Serve.
+// The key is the request path after the last slash, escaped with url.PathEscape, so the
+// node can be mounted under a prefix with http.StripPrefix, or at any path.
+// Missing keys get a 404, and errors get a 502.
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, err := url.PathUnescape(r.URL.EscapedPath()[strings.LastIndex(r.URL.EscapedPath(), "/")+1:])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := n.Serve(r.Context(), key)
+
+	switch {
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	case data == nil:
+		http.NotFound(w, r)
+	default:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(data)
+	}
+}
+
+// HTTPPeer is a Peer that fetches keys from a Node served with ServeHTTP at URL,
+// like "http://10.0.0.2:8080/cache/". Client defaults to http.DefaultClient.
+type HTTPPeer struct {
+	URL    string
+	Client *http.Client
+}
+
+// Make sure HTTPPeer satisfies the interface.
+var _ Peer = (*HTTPPeer)(nil)
+
+// Fetch requests a key from the peer.
+func (h *HTTPPeer) Fetch(ctx context.Context, key string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(h.URL, "/")+"/"+url.PathEscape(key), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting key: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("reading key: %w", err)
+		}
+
+		return data, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPeerStatus, resp.Status)
+	}
+}
@@ -0,0 +1,219 @@
+// Package cachecluster spreads a cache over a cluster of nodes, like groupcache. Each node
+// owns a segment of a consistent hash Ring, and loads the keys it owns from the origin with
+// a Getter. A node asks the owner for the other keys, so each key is loaded from the origin
+// by one node, and the cluster holds one copy of it, instead of one copy per node.
+// Keys fetched from other nodes are sometimes kept locally for a short time, so hot keys
+// end up on every node that uses them, and the owner is not asked for them every time.
+// A golift.io/cache Cache on each node stores the items.
+//
+// Data is []byte, so it can be sent between nodes. Nodes talk with a Peer; HTTPPeer and
+// Node.ServeHTTP provide one with net/http.
+package cachecluster
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"golift.io/cache"
+)
+
+// Defaults for Config.
+const (
+	defaultHotEvery = 10
+	defaultHotTTL   = time.Minute
+)
+
+// Getter loads a key from the origin, like a database. It's only called on the node that
+// owns the key. Returning nil data means the key does not exist; nothing is cached.
+// The Options are used to save the item in the owner's cache.
+type Getter func(ctx context.Context, key string) ([]byte, cache.Options, error)
+
+// Peer fetches keys from another node. Fetch returns nil data, and no error, if the key
+// does not exist. Implementations must be safe for concurrent use.
+type Peer interface {
+	Fetch(ctx context.Context, key string) ([]byte, error)
+}
+
+// PeerFunc is a Peer made from a function, like another Node's Serve method in tests.
+type PeerFunc func(ctx context.Context, key string) ([]byte, error)
+
+// Fetch calls the function.
+func (f PeerFunc) Fetch(ctx context.Context, key string) ([]byte, error) {
+	return f(ctx, key)
+}
+
+// Config configures a Node.
+type Config struct {
+	// Self is this node's name on the ring, like its address. Every node must use the
+	// same names for the same nodes.
+	Self string
+	// Peers are the other nodes, by name. Self must not be included.
+	Peers map[string]Peer
+	// Replicas is the number of points each node has on the ring. @default 50
+	Replicas int
+	// HotEvery keeps one in this many keys fetched from other nodes in the local cache.
+	// 1 keeps every key. @default 10
+	HotEvery int
+	// HotTTL is how long keys fetched from other nodes are kept. @default 1 minute
+	HotTTL time.Duration
+}
+
+// Node is a member of a cluster. Create one on each node with New, and serve it to the other
+// nodes, like with ServeHTTP. Get returns keys from the cluster. It's safe for concurrent use.
+type Node struct {
+	cache  *cache.Cache
+	getter Getter
+	config Config
+	mu     sync.RWMutex // locks ring and peers.
+	ring   *Ring
+	peers  map[string]Peer
+	flight sync.Mutex // locks calls.
+	calls  map[string]*call
+}
+
+// call is a running fetch or load that other callers for the same key wait for.
+type call struct {
+	wait sync.WaitGroup
+	data []byte
+	err  error
+}
+
+// New returns a Node that stores items in cache, and loads the keys it owns with getter.
+func New(cache *cache.Cache, getter Getter, config Config) *Node {
+	if config.HotEvery <= 0 {
+		config.HotEvery = defaultHotEvery
+	}
+
+	if config.HotTTL <= 0 {
+		config.HotTTL = defaultHotTTL
+	}
+
+	node := &Node{cache: cache, getter: getter, config: config, calls: make(map[string]*call)}
+	node.SetPeers(config.Peers)
+
+	return node
+}
+
+// SetPeers replaces the other nodes, when nodes join or leave the cluster.
+// Keys this node owned that move to another node stay in the cache until they're pruned.
+func (n *Node) SetPeers(peers map[string]Peer) {
+	names := []string{n.config.Self}
+	for name := range peers {
+		names = append(names, name)
+	}
+
+	ring := NewRing(n.config.Replicas, names...)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.ring, n.peers = ring, peers
+}
+
+// Owner returns the name of the node that owns a key.
+func (n *Node) Owner(key string) string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	return n.ring.Owner(key)
+}
+
+// Get returns the data for a key from the local cache, from the node that owns it, or from
+// the origin if this node owns it. It returns nil data, and no error, if the key does not exist.
+// Concurrent calls for the same key are coalesced, so each key is fetched once at a time.
+func (n *Node) Get(ctx context.Context, key string) ([]byte, error) {
+	if data, ok := n.cached(key); ok {
+		return data, nil
+	}
+
+	n.mu.RLock()
+	owner := n.ring.Owner(key)
+	peer := n.peers[owner]
+	n.mu.RUnlock()
+
+	if owner == n.config.Self || peer == nil {
+		return n.coalesce(ctx, key, n.load)
+	}
+
+	return n.coalesce(ctx, key, func(ctx context.Context, key string) ([]byte, error) {
+		data, err := peer.Fetch(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s from %s: %w", key, owner, err)
+		}
+
+		if data != nil && rand.Intn(n.config.HotEvery) == 0 { //nolint:gosec // not for security.
+			n.cache.Save(key, data, cache.Options{TTL: n.config.HotTTL})
+		}
+
+		return data, nil
+	})
+}
+
+// Serve answers a request from another node: it returns the data for a key from the local
+// cache, or loads it from the origin. It never asks other nodes, so requests do not loop
+// between nodes that disagree about the owner while the cluster changes.
+func (n *Node) Serve(ctx context.Context, key string) ([]byte, error) {
+	if data, ok := n.cached(key); ok {
+		return data, nil
+	}
+
+	return n.coalesce(ctx, key, n.load)
+}
+
+// cached returns the data for a key in the local cache.
+func (n *Node) cached(key string) ([]byte, bool) {
+	data, ok := n.cache.GetData(key)
+	if !ok {
+		return nil, false
+	}
+
+	bytes, ok := data.([]byte)
+
+	return bytes, ok
+}
+
+// load loads a key from the origin, and saves it in the local cache.
+func (n *Node) load(ctx context.Context, key string) ([]byte, error) {
+	data, opts, err := n.getter(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", key, err)
+	}
+
+	if data != nil {
+		n.cache.Save(key, data, opts)
+	}
+
+	return data, nil
+}
+
+// coalesce calls fn for a key, or waits for the call that's already running for it.
+func (n *Node) coalesce(ctx context.Context, key string,
+	fn func(ctx context.Context, key string) ([]byte, error),
+) ([]byte, error) {
+	n.flight.Lock()
+	if running, ok := n.calls[key]; ok {
+		n.flight.Unlock()
+		running.wait.Wait()
+
+		return running.data, running.err
+	}
+
+	running := &call{}
+	running.wait.Add(1)
+	n.calls[key] = running
+	n.flight.Unlock()
+
+	defer func() {
+		n.flight.Lock()
+		delete(n.calls, key)
+		n.flight.Unlock()
+		running.wait.Done()
+	}()
+
+	running.data, running.err = fn(ctx, key)
+
+	return running.data, running.err
+}
@@ -0,0 +1,117 @@
+package cachecluster_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"golift.io/cache"
+	"golift.io/cache/cachecluster"
+)
+
+func ExampleNew() {
+	var (
+		names = []string{"node-a", "node-b", "node-c"}
+		nodes = map[string]*cachecluster.Node{}
+		loads []string // keys loaded from the origin, and the node that loaded them.
+		mu    sync.Mutex
+	)
+
+	for _, name := range names {
+		name := name
+		local := cache.New(cache.Config{})
+		defer local.Stop(true)
+
+		nodes[name] = cachecluster.New(local, func(_ context.Context, key string) ([]byte, cache.Options, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			loads = append(loads, key+" by "+name)
+
+			return []byte("data for " + key), cache.Options{}, nil
+		}, cachecluster.Config{Self: name})
+	}
+
+	// Connect every node to the others. Use HTTPPeer to connect nodes on other hosts.
+	for _, name := range names {
+		peers := map[string]cachecluster.Peer{}
+
+		for _, peer := range names {
+			if peer != name {
+				peers[peer] = cachecluster.PeerFunc(nodes[peer].Serve)
+			}
+		}
+
+		nodes[name].SetPeers(peers)
+	}
+
+	// Every node gets every key, but each key is loaded once, by the node that owns it.
+	for _, name := range names {
+		for _, key := range []string{"admin", "guest", "luser"} {
+			data, _ := nodes[name].Get(context.Background(), key)
+			if name == "node-a" {
+				fmt.Println(string(data))
+			}
+		}
+	}
+
+	sort.Strings(loads)
+	fmt.Println(loads)
+	fmt.Println("admin is owned by", nodes["node-b"].Owner("admin"))
+	// Output:
+	// data for admin
+	// data for guest
+	// data for luser
+	// [admin by node-c guest by node-b luser by node-a]
+	// admin is owned by node-c
+}
+
+func TestHTTPPeer(t *testing.T) {
+	t.Parallel()
+
+	local := cache.New(cache.Config{})
+	t.Cleanup(func() { local.Stop(true) })
+
+	owner := cachecluster.New(local, func(_ context.Context, key string) ([]byte, cache.Options, error) {
+		if key == "missing" {
+			return nil, cache.Options{}, nil
+		}
+
+		return []byte("data for " + key), cache.Options{}, nil
+	}, cachecluster.Config{Self: "owner"})
+
+	server := httptest.NewServer(http.StripPrefix("/cache/", owner))
+	t.Cleanup(server.Close)
+
+	remote := cache.New(cache.Config{})
+	t.Cleanup(func() { remote.Stop(true) })
+
+	client := cachecluster.New(remote, nil, cachecluster.Config{
+		Self:     "client",
+		Peers:    map[string]cachecluster.Peer{"owner": &cachecluster.HTTPPeer{URL: server.URL + "/cache/"}},
+		HotEvery: 1,
+	})
+
+	for _, key := range []string{"x y", "z/z", "key?"} {
+		if client.Owner(key) != "owner" {
+			t.Fatalf("%q is not owned by the peer; pick another key", key)
+		}
+
+		data, err := client.Get(context.Background(), key)
+		if err != nil || string(data) != "data for "+key {
+			t.Errorf("Get(%q) = %q, %v", key, data, err)
+		}
+
+		if _, ok := remote.GetData(key); !ok {
+			t.Errorf("hot key %q was not kept locally", key)
+		}
+	}
+
+	if data, err := client.Get(context.Background(), "missing"); data != nil || err != nil {
+		t.Errorf("Get(missing) = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,64 @@
+package cachecluster
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// defaultReplicas is the number of points each node has on the ring.
+const defaultReplicas = 50
+
+// Ring is a consistent hash ring. Each node has many points on the ring, and a key is
+// owned by the node with the first point at or after the key's hash. Adding or removing
+// a node only moves the keys next to its points. A Ring is not safe for concurrent use.
+type Ring struct {
+	replicas int
+	points   []uint32          // sorted.
+	owners   map[uint32]string // node names, by point.
+}
+
+// NewRing returns a ring with nodes, each with replicas points on the ring.
+// Pass 0 replicas for the default, 50. Every node in a cluster must use the same replicas.
+func NewRing(replicas int, nodes ...string) *Ring {
+	if replicas <= 0 {
+		replicas = defaultReplicas
+	}
+
+	ring := &Ring{replicas: replicas, owners: make(map[uint32]string)}
+	ring.Add(nodes...)
+
+	return ring
+}
+
+// Add adds nodes to the ring.
+func (r *Ring) Add(nodes ...string) {
+	for _, node := range nodes {
+		for idx := 0; idx < r.replicas; idx++ {
+			point := crc32.ChecksumIEEE([]byte(strconv.Itoa(idx) + node))
+			if _, ok := r.owners[point]; !ok {
+				r.points = append(r.points, point)
+			}
+
+			r.owners[point] = node
+		}
+	}
+
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+// Owner returns the node that owns a key, or an empty string if the ring has no nodes.
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(key))
+	idx := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+
+	if idx == len(r.points) {
+		idx = 0 // wrap around the ring.
+	}
+
+	return r.owners[r.points[idx]]
+}
@@ -0,0 +1,332 @@
+// Package cachedns caches DNS lookups in a golift.io/cache Cache. Resolver has the
+// LookupHost, LookupIP and LookupIPAddr methods of net.Resolver, and a DialContext
+// method for net/http Transports and other dialers, so repeated connections to the
+// same hosts do not query DNS every time.
+//
+// Answers are cached for the smallest TTL in the DNS responses. Go's resolver does not
+// return TTLs, so Resolver reads them from the responses as they pass through its Dial
+// function. Names answered without a DNS query, like names in /etc/hosts, are cached for
+// DefaultTTL. The pure Go resolver is always used, because the cgo resolver does not dial.
+package cachedns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"golift.io/cache"
+)
+
+// Config controls how long answers are cached, and how DNS servers are reached.
+type Config struct {
+	// Dial connects to a DNS server, like net.Resolver.Dial.
+	// @default nil, dial the servers in /etc/resolv.conf.
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+	// DefaultTTL is used when a lookup's responses have no TTL, like names in /etc/hosts.
+	// @default 1 minute
+	DefaultTTL time.Duration
+	// MinTTL and MaxTTL bound the TTLs from DNS responses. @default 0, no bounds.
+	MinTTL time.Duration
+	MaxTTL time.Duration
+	// NegativeTTL caches "no such host" answers for this long. @default 0, not cached.
+	NegativeTTL time.Duration
+}
+
+// defaultTTL is the default Config.DefaultTTL.
+const defaultTTL = time.Minute
+
+// Resolver looks up host names, and caches the answers.
+type Resolver struct {
+	cache    *cache.Cache
+	config   Config
+	resolver *net.Resolver
+	dialer   net.Dialer
+}
+
+// ttlKey is the context key for the ttlRecorder of a lookup.
+type ttlKey struct{}
+
+// ttlRecorder keeps the smallest TTL in the DNS responses for a lookup.
+type ttlRecorder struct {
+	mu  sync.Mutex
+	ttl uint32
+	set bool
+}
+
+// ttlConn is a connection to a DNS server that records the TTLs in the responses read from it.
+type ttlConn struct {
+	net.Conn
+	recorder *ttlRecorder
+	stream   bool   // TCP: messages have a 2-byte length prefix, and may span reads.
+	buf      []byte // partial messages read from a stream.
+}
+
+// ttlPacketConn is a ttlConn for UDP.
+type ttlPacketConn struct {
+	*ttlConn
+	packet net.PacketConn
+}
+
+// New returns a Resolver that caches answers in a cache.
+// Use a cache Namespace if the cache has other data.
+func New(cache *cache.Cache, config Config) *Resolver {
+	if config.DefaultTTL == 0 {
+		config.DefaultTTL = defaultTTL
+	}
+
+	r := &Resolver{cache: cache, config: config}
+	r.resolver = &net.Resolver{PreferGo: true, Dial: r.dial}
+
+	return r
+}
+
+// LookupIPAddr returns the addresses of a host, from the cache if it's there.
+// The returned slice is a copy, and may be changed.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if item := r.cache.Get(host); item != nil {
+		if err, ok := item.Data.(error); ok && item.Negative {
+			return nil, fmt.Errorf("looking up host: %w", err)
+		}
+
+		if addrs, ok := item.Data.([]net.IPAddr); ok {
+			return append([]net.IPAddr(nil), addrs...), nil
+		}
+	}
+
+	recorder := &ttlRecorder{}
+
+	addrs, err := r.resolver.LookupIPAddr(context.WithValue(ctx, ttlKey{}, recorder), host)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound && r.config.NegativeTTL > 0 {
+			r.cache.SaveNegative(host, err, r.config.NegativeTTL)
+		}
+
+		return nil, fmt.Errorf("looking up host: %w", err)
+	}
+
+	r.cache.Save(host, append([]net.IPAddr(nil), addrs...), cache.Options{TTL: r.ttl(recorder)})
+
+	return addrs, nil
+}
+
+// LookupIP returns the addresses of a host for a network: "ip", "ip4" or "ip6".
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	if network != "ip" && network != "ip4" && network != "ip6" {
+		return nil, &net.DNSError{Err: "unknown network " + network, Name: host}
+	}
+
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+
+	for _, addr := range addrs {
+		if network == "ip" || (network == "ip4") == (addr.IP.To4() != nil) {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no suitable address found", Name: host, IsNotFound: true}
+	}
+
+	return ips, nil
+}
+
+// LookupHost returns the addresses of a host as strings.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]string, len(addrs))
+	for idx, addr := range addrs {
+		hosts[idx] = addr.String()
+	}
+
+	return hosts, nil
+}
+
+// DialContext connects to an address, like net.Dialer.DialContext, and looks up the host
+// with the cache. The addresses are tried in order until one connects.
+func (r *Resolver) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("dialing: %w", err)
+	}
+
+	if net.ParseIP(host) != nil {
+		return r.dialer.DialContext(ctx, network, address) //nolint:wrapcheck // it's the dialer.
+	}
+
+	hosts, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ip := range hosts {
+		var conn net.Conn
+		if conn, err = r.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+			return conn, nil
+		}
+	}
+
+	return nil, fmt.Errorf("dialing %s: %w", address, err)
+}
+
+// ttl returns how long to cache a lookup with the TTLs its recorder saw.
+func (r *Resolver) ttl(recorder *ttlRecorder) time.Duration {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	if !recorder.set {
+		return r.config.DefaultTTL
+	}
+
+	ttl := max(time.Duration(recorder.ttl)*time.Second, r.config.MinTTL)
+	if r.config.MaxTTL > 0 {
+		ttl = min(ttl, r.config.MaxTTL)
+	}
+
+	if ttl == 0 { // a 0 TTL means do not cache, but a 0 cache TTL never expires.
+		ttl = time.Nanosecond
+	}
+
+	return ttl
+}
+
+// dial connects to a DNS server, and records the TTLs in its responses for the lookup in ctx.
+func (r *Resolver) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	dial := r.config.Dial
+	if dial == nil {
+		dial = r.dialer.DialContext
+	}
+
+	conn, err := dial(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder, _ := ctx.Value(ttlKey{}).(*ttlRecorder)
+	if recorder == nil {
+		return conn, nil
+	}
+
+	// The resolver frames messages for UDP if the connection is a PacketConn, so keep it one.
+	if packet, ok := conn.(net.PacketConn); ok {
+		return &ttlPacketConn{ttlConn: &ttlConn{Conn: conn, recorder: recorder}, packet: packet}, nil
+	}
+
+	return &ttlConn{Conn: conn, recorder: recorder, stream: true}, nil
+}
+
+// ReadFrom reads a packet from the DNS server, without recording its TTLs. The resolver calls Read.
+func (c *ttlPacketConn) ReadFrom(data []byte) (int, net.Addr, error) {
+	return c.packet.ReadFrom(data) //nolint:wrapcheck // it's the connection.
+}
+
+// WriteTo writes a packet to an address.
+func (c *ttlPacketConn) WriteTo(data []byte, addr net.Addr) (int, error) {
+	return c.packet.WriteTo(data, addr) //nolint:wrapcheck // it's the connection.
+}
+
+// Read reads from the DNS server, and records the TTLs in the responses.
+func (c *ttlConn) Read(data []byte) (int, error) {
+	size, err := c.Conn.Read(data)
+	if !c.stream {
+		c.recorder.record(data[:size])
+		return size, err //nolint:wrapcheck // it's the connection.
+	}
+
+	c.buf = append(c.buf, data[:size]...)
+
+	for len(c.buf) >= 2 {
+		length := int(binary.BigEndian.Uint16(c.buf)) + 2 //nolint:mnd // 2-byte length prefix.
+		if len(c.buf) < length {
+			break
+		}
+
+		c.recorder.record(c.buf[2:length])
+		c.buf = c.buf[length:]
+	}
+
+	return size, err //nolint:wrapcheck // it's the connection.
+}
+
+// record saves the smallest TTL in the answers of a DNS message.
+// Messages that can not be parsed are ignored.
+func (t *ttlRecorder) record(msg []byte) {
+	ttl, ok := minTTL(msg)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.set || ttl < t.ttl {
+		t.ttl, t.set = ttl, true
+	}
+}
+
+// minTTL returns the smallest TTL in the answer section of a DNS message,
+// and false if there are no answers, or the message can not be parsed.
+func minTTL(msg []byte) (uint32, bool) {
+	const (
+		headerSize   = 12
+		questionTail = 4  // type and class.
+		answerTail   = 10 // type, class, TTL and data length.
+	)
+
+	if len(msg) < headerSize {
+		return 0, false
+	}
+
+	questions, answers := binary.BigEndian.Uint16(msg[4:]), binary.BigEndian.Uint16(msg[6:])
+	offset := headerSize
+
+	for idx := uint16(0); idx < questions; idx++ {
+		if offset = skipName(msg, offset) + questionTail; offset > len(msg) {
+			return 0, false
+		}
+	}
+
+	ttl := uint32(math.MaxUint32)
+
+	for idx := uint16(0); idx < answers; idx++ {
+		if offset = skipName(msg, offset); offset+answerTail > len(msg) {
+			return 0, false
+		}
+
+		ttl = min(ttl, binary.BigEndian.Uint32(msg[offset+4:]))
+		offset += answerTail + int(binary.BigEndian.Uint16(msg[offset+8:]))
+	}
+
+	return ttl, answers > 0 && offset <= len(msg)
+}
+
+// skipName returns the offset after a (possibly compressed) name in a DNS message,
+// or an offset past the end of the message if the name is cut off.
+func skipName(msg []byte, offset int) int {
+	for offset < len(msg) {
+		switch length := int(msg[offset]); {
+		case length == 0:
+			return offset + 1
+		case length&0xC0 == 0xC0: // a pointer to a name earlier in the message.
+			return offset + 2 //nolint:mnd // 2-byte pointer.
+		default:
+			offset += length + 1
+		}
+	}
+
+	return len(msg) + 1
+}
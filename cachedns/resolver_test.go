@@ -0,0 +1,128 @@
+package cachedns_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golift.io/cache"
+	"golift.io/cache/cachedns"
+)
+
+// dnsServer answers A queries for app.example. with 192.0.2.1 and a TTL of 300 seconds,
+// AAAA queries with no answers, and every other name with "no such host".
+type dnsServer struct {
+	conn    net.PacketConn
+	queries atomic.Int32
+}
+
+func newDNSServer(t *testing.T) *dnsServer {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	server := &dnsServer{conn: conn}
+	go server.serve()
+
+	return server
+}
+
+func (d *dnsServer) serve() {
+	buf := make([]byte, 512)
+
+	for {
+		size, addr, err := d.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		d.queries.Add(1)
+		d.conn.WriteTo(answer(buf[:size]), addr) //nolint:errcheck
+	}
+}
+
+// answer returns the response to a query with one question.
+func answer(query []byte) []byte {
+	end := 12
+	for query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+
+	name, qtype := string(query[12:end+1]), binary.BigEndian.Uint16(query[end+1:])
+	question := query[12 : end+5]
+	resp := binary.BigEndian.AppendUint16(append([]byte{}, query[:2]...), 0x8180) // response, no error.
+
+	switch {
+	case name != "\x03app\x07example\x00":
+		resp[3] |= 3 // no such host.
+		fallthrough
+	case qtype != 1: // not an A query.
+		resp = binary.BigEndian.AppendUint16(resp, 1)
+		resp = append(resp, 0, 0, 0, 0, 0, 0)
+
+		return append(resp, question...)
+	}
+
+	resp = append(resp, 0, 1, 0, 1, 0, 0, 0, 0)
+	resp = append(resp, question...)
+	resp = append(resp, 0xC0, 12, 0, 1, 0, 1) // the name in the question, A, IN.
+	resp = binary.BigEndian.AppendUint32(resp, 300)
+
+	return append(resp, 0, 4, 192, 0, 2, 1)
+}
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	server := newDNSServer(t)
+	dnsCache := cache.New(cache.Config{})
+	t.Cleanup(func() { dnsCache.Stop(true) })
+
+	resolver := cachedns.New(dnsCache, cachedns.Config{
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, server.conn.LocalAddr().String())
+		},
+		NegativeTTL: time.Minute,
+	})
+	ctx := context.Background()
+
+	for idx := 0; idx < 3; idx++ {
+		hosts, err := resolver.LookupHost(ctx, "app.example.")
+		if err != nil || len(hosts) != 1 || hosts[0] != "192.0.2.1" {
+			t.Fatalf("wrong hosts: %v, error: %v", hosts, err)
+		}
+	}
+
+	if queries := server.queries.Load(); queries != 2 { // A and AAAA, once.
+		t.Errorf("expected 2 queries, got %d", queries)
+	}
+
+	item := dnsCache.Get("app.example.")
+	if ttl := item.Expires.Sub(item.Time); ttl != 300*time.Second {
+		t.Errorf("expected the answer's 300 second TTL, got %v", ttl)
+	}
+
+	if ips, err := resolver.LookupIP(ctx, "ip6", "app.example."); err == nil {
+		t.Errorf("expected no IPv6 addresses, got %v", ips)
+	}
+
+	for idx := 0; idx < 2; idx++ {
+		var dnsErr *net.DNSError
+		if _, err := resolver.LookupIPAddr(ctx, "missing.example."); !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+	}
+
+	if queries := server.queries.Load(); queries != 4 { // the missing name was cached.
+		t.Errorf("expected 4 queries, got %d", queries)
+	}
+}
@@ -0,0 +1,170 @@
+// Package cachefile caches file contents, or files parsed into something else like templates,
+// keyed by path. Files are watched with fsnotify, and removed from the cache when they change,
+// so the next Get reads the new file. Save them with Options like MaxUnused, so the cache
+// pruner removes files that are rarely used.
+//
+// This package is its own Go module, so the cache module does not depend on fsnotify.
+package cachefile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"golift.io/cache"
+)
+
+// Parser turns a file's contents into the data that's cached for it.
+type Parser[T any] func(path string, data []byte) (T, error)
+
+// Contents is a Parser that caches the file contents.
+func Contents(_ string, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// Files reads and parses files, and caches the results until the files change.
+// Create one with New, and Close it when it's no longer used.
+type Files[T any] struct {
+	cache   *cache.Cache
+	parse   Parser[T]
+	opts    cache.Options
+	watcher *fsnotify.Watcher
+	mu      sync.Mutex
+	dirs    map[string]struct{} // watched directories.
+	changes uint64              // counts changes and flushes, to skip saving files that changed while read.
+	done    chan struct{}
+}
+
+// New returns a Files that caches files parsed with parse, saved with opts. The cache
+// keys are the cleaned, absolute paths; use a cache Namespace if the cache has other data.
+// If fsnotify reports an error, like a full event queue, changes may have been missed,
+// so every item in the cache, or namespace, is flushed.
+func New[T any](cache *cache.Cache, parse Parser[T], opts cache.Options) (*Files[T], error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("watching files: %w", err)
+	}
+
+	files := &Files[T]{
+		cache:   cache,
+		parse:   parse,
+		opts:    opts,
+		watcher: watcher,
+		dirs:    make(map[string]struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go files.watch()
+
+	return files, nil
+}
+
+// Get returns the parsed file at path from the cache, or reads, parses and caches it.
+// Errors are not cached. If any watched file changes while it's read, the file is returned,
+// but not cached, so a file that changed while it was read is not kept.
+func (f *Files[T]) Get(path string) (T, error) {
+	var empty T
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return empty, fmt.Errorf("finding file: %w", err)
+	}
+
+	if data, ok := f.cache.GetData(path); ok {
+		if parsed, ok := data.(T); ok {
+			return parsed, nil
+		}
+	}
+
+	// Watch the directory, not the file, so files replaced by a rename are seen.
+	changes, err := f.watchDir(path)
+	if err != nil {
+		return empty, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return empty, fmt.Errorf("reading file: %w", err)
+	}
+
+	parsed, err := f.parse(path, data)
+	if err != nil {
+		return empty, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.changes == changes {
+		f.cache.Save(path, parsed, f.opts)
+	}
+
+	return parsed, nil
+}
+
+// watchDir watches the directory of a file, and returns the change counter.
+func (f *Files[T]) watchDir(path string) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dir := filepath.Dir(path)
+	if _, ok := f.dirs[dir]; !ok {
+		if err := f.watcher.Add(dir); err != nil {
+			return 0, fmt.Errorf("watching %s: %w", dir, err)
+		}
+
+		f.dirs[dir] = struct{}{}
+	}
+
+	return f.changes, nil
+}
+
+// watch deletes changed files from the cache until the watcher is closed.
+func (f *Files[T]) watch() {
+	defer close(f.done)
+
+	for {
+		select {
+		case event, ok := <-f.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if event.Op != fsnotify.Chmod {
+				f.changed(event.Name)
+			}
+		case _, ok := <-f.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			f.mu.Lock()
+			f.changes++
+			f.cache.Flush()
+			f.mu.Unlock()
+		}
+	}
+}
+
+// changed deletes a changed file from the cache.
+func (f *Files[T]) changed(path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.changes++
+	f.cache.Delete(path)
+}
+
+// Close stops watching files. Cached files are not removed, and are not updated if they change.
+func (f *Files[T]) Close() error {
+	err := f.watcher.Close()
+	<-f.done
+
+	if err != nil {
+		return fmt.Errorf("closing watcher: %w", err)
+	}
+
+	return nil
+}
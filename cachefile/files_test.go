@@ -0,0 +1,85 @@
+package cachefile_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"golift.io/cache"
+	"golift.io/cache/cachefile"
+)
+
+func ExampleNew() {
+	dir, _ := os.MkdirTemp("", "cachefile")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hello.tmpl")
+	_ = os.WriteFile(path, []byte("Hello, {{.}}!\n"), 0o600)
+
+	templateCache := cache.New(cache.Config{})
+	defer templateCache.Stop(true)
+
+	templates, err := cachefile.New(templateCache,
+		func(path string, data []byte) (*template.Template, error) {
+			return template.New(filepath.Base(path)).Parse(string(data))
+		}, cache.Options{MaxUnused: time.Hour})
+	if err != nil {
+		panic(err)
+	}
+	defer templates.Close()
+
+	hello, _ := templates.Get(path)
+	_ = hello.Execute(os.Stdout, "admin")
+	// Output:
+	// Hello, admin!
+}
+
+func TestFilesChanged(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("one"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fileCache := cache.New(cache.Config{})
+	t.Cleanup(func() { fileCache.Stop(true) })
+
+	files, err := cachefile.New(fileCache, cachefile.Contents, cache.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { files.Close() })
+
+	if data, err := files.Get(path); err != nil || string(data) != "one" {
+		t.Fatalf("wrong data: %q, error: %v", data, err)
+	}
+
+	cached := func() bool { return slices.Contains(fileCache.Keys(), path) }
+
+	if !cached() {
+		t.Fatal("the file was not cached")
+	}
+
+	if err := os.WriteFile(path, []byte("two"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); cached(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the changed file was not removed from the cache")
+		}
+	}
+
+	if data, err := files.Get(path); err != nil || string(data) != "two" {
+		t.Fatalf("wrong data: %q, error: %v", data, err)
+	}
+
+	if _, err := files.Get(path + ".missing"); err == nil || !strings.Contains(err.Error(), "reading file") {
+		t.Errorf("expected a read error, got %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+module golift.io/cache/cachefile
+
+go 1.23
+
+replace golift.io/cache => ../
+
+require (
+	github.com/fsnotify/fsnotify v1.10.1
+	golift.io/cache v0.0.0
+)
+
+require golang.org/x/sys v0.13.0 // indirect
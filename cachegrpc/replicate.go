@@ -0,0 +1,143 @@
+package cachegrpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golift.io/cache"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Replication defaults.
+const (
+	defaultReplicaQueue   = 1024
+	defaultReplicaTimeout = 5 * time.Second
+)
+
+// ErrQueueFull is passed to OnError when a change is dropped because a peer's queue is full.
+var ErrQueueFull = errors.New("replication queue is full")
+
+// ReplicatorConfig configures a Replicator.
+type ReplicatorConfig struct {
+	// Peers are clients for the other caches, each served by a Server.
+	Peers []CacheClient
+	// Queue is the number of saves and deletes that may wait to be sent to each peer.
+	// When a peer's queue is full, new changes for it are dropped. @default 1024
+	Queue int
+	// Timeout limits each request to a peer. @default 5 seconds
+	Timeout time.Duration
+	// OnError is called when a change is not sent to a peer: in the peer's go routine when
+	// the request fails, and in the caller's go routine when the peer's queue is full.
+	// It must be safe for concurrent use. @default nil, errors are ignored.
+	OnError func(peer int, key string, err error)
+}
+
+// Replicator saves and deletes items in a cache, and sends the same changes to peer caches,
+// so a small cluster keeps warm caches roughly in sync without a central server. Changes are
+// sent in the background, in order, to each peer; delivery is best-effort. Each node runs a
+// Server for its peers, and a Replicator for its own changes. Changes received by the Server
+// are not sent on, so they do not loop between peers. Data is []byte, like the Server stores,
+// and only the Prune and Expire (or TTL) options are sent.
+type Replicator struct {
+	cache  *cache.Cache
+	config ReplicatorConfig
+	queues []chan *SaveRequest
+	wait   sync.WaitGroup
+}
+
+// NewReplicator returns a Replicator that saves changes in cache, and sends them to the peers.
+// It starts a go routine for each peer; call Close to stop them.
+func NewReplicator(cache *cache.Cache, config ReplicatorConfig) *Replicator {
+	if config.Queue <= 0 {
+		config.Queue = defaultReplicaQueue
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = defaultReplicaTimeout
+	}
+
+	replicator := &Replicator{cache: cache, config: config}
+
+	for peer, client := range config.Peers {
+		queue := make(chan *SaveRequest, config.Queue)
+		replicator.queues = append(replicator.queues, queue)
+		replicator.wait.Add(1)
+
+		go replicator.send(peer, client, queue)
+	}
+
+	return replicator
+}
+
+// Save saves an item in the cache, and queues it for the peers. Saving nil data deletes the key.
+// It returns true if the key existed in this cache.
+func (r *Replicator) Save(key string, data []byte, opts cache.Options) bool {
+	if opts.TTL > 0 && opts.Expire.IsZero() {
+		opts.Expire = time.Now().Add(opts.TTL)
+	}
+
+	var saved any
+	if len(data) > 0 {
+		saved = data
+	}
+
+	updated := r.cache.Save(key, saved, opts)
+	req := &SaveRequest{Key: key, Data: data, Prune: opts.Prune}
+
+	if !opts.Expire.IsZero() {
+		req.Expire = timestamppb.New(opts.Expire)
+	}
+
+	r.queue(req)
+
+	return updated
+}
+
+// Delete deletes an item from the cache, and queues the delete for the peers.
+// It returns true if the key existed in this cache.
+func (r *Replicator) Delete(key string) bool {
+	deleted := r.cache.Delete(key)
+	r.queue(&SaveRequest{Key: key}) // saving empty data deletes the key.
+
+	return deleted
+}
+
+// Close stops sending changes after the queued changes are sent, and waits for that.
+// Do not call Save or Delete after Close.
+func (r *Replicator) Close() {
+	for _, queue := range r.queues {
+		close(queue)
+	}
+
+	r.wait.Wait()
+}
+
+// queue adds a change to every peer's queue, or drops it for peers with a full queue.
+func (r *Replicator) queue(req *SaveRequest) {
+	for peer, queue := range r.queues {
+		select {
+		case queue <- req:
+		default:
+			if r.config.OnError != nil {
+				r.config.OnError(peer, req.GetKey(), ErrQueueFull)
+			}
+		}
+	}
+}
+
+// send sends the queued changes to a peer until the queue is closed.
+func (r *Replicator) send(peer int, client CacheClient, queue chan *SaveRequest) {
+	defer r.wait.Done()
+
+	for req := range queue {
+		ctx, cancel := context.WithTimeout(context.Background(), r.config.Timeout)
+		_, err := client.Save(ctx, req)
+		cancel()
+
+		if err != nil && r.config.OnError != nil {
+			r.config.OnError(peer, req.GetKey(), err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package cachegrpc_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"golift.io/cache"
+	"golift.io/cache/cachegrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// peer serves a cache with a Server, and returns a client for it.
+func peer(shared *cache.Cache) (cachegrpc.CacheClient, func()) {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	cachegrpc.RegisterCacheServer(server, cachegrpc.NewServer(shared))
+
+	go server.Serve(listener) //nolint:errcheck // stopped below.
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		panic(err)
+	}
+
+	return cachegrpc.NewCacheClient(conn), func() { conn.Close(); server.Stop() }
+}
+
+func ExampleNewReplicator() {
+	// Two nodes, each with its own cache served to the other.
+	first := cache.New(cache.Config{})
+	defer first.Stop(true)
+
+	second := cache.New(cache.Config{})
+	defer second.Stop(true)
+
+	secondClient, stop := peer(second)
+	defer stop()
+
+	replicator := cachegrpc.NewReplicator(first, cachegrpc.ReplicatorConfig{
+		Peers:   []cachegrpc.CacheClient{secondClient},
+		OnError: func(peer int, key string, err error) { fmt.Println(peer, key, err) },
+	})
+
+	replicator.Save("admin", []byte("Super Dooper"), cache.Options{})
+	replicator.Save("guest", []byte("Nobody"), cache.Options{})
+	replicator.Delete("guest")
+	replicator.Close() // waits for the queued changes.
+
+	fmt.Println(first.Keys(), second.Keys(), string(second.Get("admin").Data.([]byte)))
+	// Output:
+	// [admin] [admin] Super Dooper
+}
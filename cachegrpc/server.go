@@ -0,0 +1,142 @@
+// Package cachegrpc provides a gRPC service around a golift.io/cache Cache,
+// so a cache embedded in a sidecar can be used through a typed RPC surface.
+// The service is defined in cache.proto. Values are stored as []byte;
+// cached strings saved by Go code are served too. A Replicator sends the saves and deletes
+// made through it to the Servers of peer caches, to keep a small cluster's caches in sync.
+//
+// This package is its own Go module, so the cache module does not depend on gRPC.
+package cachegrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative cache.proto
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"golift.io/cache"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements CacheServer with a cache.
+type Server struct {
+	UnimplementedCacheServer
+	cache *cache.Cache
+}
+
+// NewServer returns a gRPC service that stores items in the provided cache.
+// Register it with RegisterCacheServer. Use a cache Namespace if the cache is used for other data too.
+func NewServer(cache *cache.Cache) *Server {
+	return &Server{cache: cache}
+}
+
+// Get returns an item, and updates hit/miss stats.
+// Found is false if the key does not exist.
+func (s *Server) Get(_ context.Context, req *GetRequest) (*GetResponse, error) {
+	item := s.cache.Get(req.GetKey())
+	if item == nil {
+		return &GetResponse{}, nil
+	}
+
+	msg, err := toItem(req.GetKey(), item)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetResponse{Found: true, Item: msg}, nil
+}
+
+// Save saves an item. Saving empty data deletes the key, like saving nil data in the cache.
+func (s *Server) Save(_ context.Context, req *SaveRequest) (*SaveResponse, error) {
+	opts := cache.Options{Prune: req.GetPrune()}
+	if req.GetExpire() != nil {
+		opts.Expire = req.GetExpire().AsTime()
+	}
+
+	var data any
+	if len(req.GetData()) > 0 {
+		data = req.GetData()
+	}
+
+	return &SaveResponse{Updated: s.cache.Save(req.GetKey(), data, opts)}, nil
+}
+
+// Delete deletes an item.
+func (s *Server) Delete(_ context.Context, req *DeleteRequest) (*DeleteResponse, error) {
+	return &DeleteResponse{Deleted: s.cache.Delete(req.GetKey())}, nil
+}
+
+// List streams every item in the cache, sorted by key. Items that are not
+// []byte or strings are skipped. The cache is copied before streaming begins.
+func (s *Server) List(_ *ListRequest, stream grpc.ServerStreamingServer[Item]) error {
+	items := s.cache.List()
+	keys := make([]string, 0, len(items))
+
+	for key := range items {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		msg, err := toItem(key, items[key])
+		if err != nil {
+			continue
+		}
+
+		if err := stream.Send(msg); err != nil {
+			return err //nolint:wrapcheck // already a status error.
+		}
+	}
+
+	return nil
+}
+
+// Stats returns the cache statistics.
+func (s *Server) Stats(context.Context, *StatsRequest) (*StatsResponse, error) {
+	stats := s.cache.Stats()
+
+	return &StatsResponse{
+		Size:        stats.Size,
+		Gets:        stats.Gets,
+		Hits:        stats.Hits,
+		Misses:      stats.Misses,
+		Saves:       stats.Saves,
+		Updates:     stats.Updates,
+		Deletes:     stats.Deletes,
+		DelMiss:     stats.DelMiss,
+		Pruned:      stats.Pruned,
+		Prunes:      stats.Prunes,
+		Pruning:     durationpb.New(stats.Pruning.Duration),
+		Refreshes:   stats.Refreshes,
+		RefreshErrs: stats.RefreshErrs,
+	}, nil
+}
+
+// toItem converts a cache item into a message.
+func toItem(key string, item *cache.Item) (*Item, error) {
+	msg := &Item{Key: key, Time: timestamp(item.Time), Last: timestamp(item.Last), Hits: item.Hits}
+
+	switch data := item.Data.(type) {
+	case []byte:
+		msg.Data = data
+	case string:
+		msg.Data = []byte(data)
+	default:
+		return nil, status.Errorf(codes.FailedPrecondition, "cached data for %q is %T, not bytes", key, data)
+	}
+
+	return msg, nil
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
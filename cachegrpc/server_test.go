@@ -0,0 +1,62 @@
+package cachegrpc_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"golift.io/cache"
+	"golift.io/cache/cachegrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func ExampleNewServer() {
+	shared := cache.New(cache.Config{})
+	defer shared.Stop(true)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	cachegrpc.RegisterCacheServer(server, cachegrpc.NewServer(shared))
+
+	go server.Serve(listener) //nolint:errcheck // stopped below.
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	client := cachegrpc.NewCacheClient(conn)
+
+	client.Save(ctx, &cachegrpc.SaveRequest{Key: "b", Data: []byte("bee")})
+	client.Save(ctx, &cachegrpc.SaveRequest{Key: "a", Data: []byte("ay")})
+
+	got, _ := client.Get(ctx, &cachegrpc.GetRequest{Key: "a"})
+	fmt.Println("Get:", got.GetFound(), string(got.GetItem().GetData()))
+
+	stream, _ := client.List(ctx, &cachegrpc.ListRequest{})
+
+	for {
+		item, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		fmt.Println("List:", item.GetKey(), string(item.GetData()), item.GetHits())
+	}
+
+	stats, _ := client.Stats(ctx, &cachegrpc.StatsRequest{})
+	fmt.Println("Size:", stats.GetSize(), "Hits:", stats.GetHits())
+	// Output:
+	// Get: true ay
+	// List: a ay 1
+	// List: b bee 0
+	// Size: 2 Hits: 1
+}
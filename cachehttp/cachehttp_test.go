@@ -0,0 +1,226 @@
+package cachehttp_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"golift.io/cache"
+	"golift.io/cache/cachehttp"
+)
+
+func ExampleNewTransport() {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, "expensive answer")
+	}))
+	defer api.Close()
+
+	responses := cache.New(cache.Config{})
+	defer responses.Stop(true)
+
+	client := &http.Client{Transport: cachehttp.NewTransport(responses)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(api.URL)
+		if err != nil {
+			panic(err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("%s (cached: %q)\n", body, resp.Header.Get(cachehttp.HeaderCache))
+	}
+	// Output:
+	// expensive answer (cached: "")
+	// expensive answer (cached: "1")
+}
+
+func TestTransportRevalidate(t *testing.T) {
+	t.Parallel()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=0")
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		fmt.Fprint(w, "body")
+	}))
+	defer api.Close()
+
+	responses := cache.New(cache.Config{})
+	defer responses.Stop(true)
+
+	client := &http.Client{Transport: cachehttp.NewTransport(responses)}
+	wait := sync.WaitGroup{}
+
+	// Stale responses are revalidated concurrently; run this with -race.
+	for i := 0; i < 20; i++ {
+		wait.Add(1)
+
+		go func() {
+			defer wait.Done()
+
+			resp, err := client.Get(api.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+
+			if body, _ := io.ReadAll(resp.Body); string(body) != "body" {
+				t.Errorf("wrong body: %q", body)
+			}
+		}()
+	}
+
+	wait.Wait()
+}
+
+func TestTransportVary(t *testing.T) {
+	t.Parallel()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprint(w, "hello in "+r.Header.Get("Accept-Language"))
+	}))
+	defer api.Close()
+
+	responses := cache.New(cache.Config{})
+	defer responses.Stop(true)
+
+	client := &http.Client{Transport: cachehttp.NewTransport(responses)}
+
+	for _, lang := range []string{"en", "de", "en", "de"} {
+		req, _ := http.NewRequest(http.MethodGet, api.URL, nil)
+		req.Header.Set("Accept-Language", lang)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if string(body) != "hello in "+lang {
+			t.Errorf("request for %s got %q (cached: %q)", lang, body, resp.Header.Get(cachehttp.HeaderCache))
+		}
+	}
+}
+
+func TestTransportAuthorization(t *testing.T) {
+	t.Parallel()
+
+	public := false
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		if public {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		}
+
+		fmt.Fprint(w, "account of "+r.Header.Get("Authorization"))
+	}))
+	defer api.Close()
+
+	responses := cache.New(cache.Config{})
+	defer responses.Stop(true)
+
+	client := &http.Client{Transport: cachehttp.NewTransport(responses)}
+	get := func(user string) (string, bool) {
+		t.Helper()
+
+		req, _ := http.NewRequest(http.MethodGet, api.URL, nil)
+		req.Header.Set("Authorization", user)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return string(body), resp.Header.Get(cachehttp.HeaderCache) != ""
+	}
+
+	if body, _ := get("alice"); body != "account of alice" {
+		t.Fatalf("wrong body: %q", body)
+	}
+
+	if body, cached := get("bob"); body != "account of bob" || cached {
+		t.Errorf("an authorized response was returned to another user: %q (cached: %v)", body, cached)
+	}
+
+	public = true
+	get("alice")
+
+	if _, cached := get("bob"); !cached {
+		t.Error("a public response to an authorized request was not cached")
+	}
+}
+
+func TestMiddlewareTTL(t *testing.T) {
+	t.Parallel()
+
+	pages := cache.New(cache.Config{}) // no pruner.
+	defer pages.Stop(true)
+
+	renders := 0
+	handler := cachehttp.Middleware(pages, nil, 10*time.Millisecond)(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			renders++
+			fmt.Fprint(w, "rendered page")
+		}))
+
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/page", nil))
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/page", nil))
+
+	if renders != 2 {
+		t.Errorf("the handler must run again after the ttl passes, renders: %d", renders)
+	}
+
+	if rec.Header().Get(cachehttp.HeaderCache) != "" {
+		t.Error("a stale response was served from cache")
+	}
+}
+
+func ExampleMiddleware() {
+	pages := cache.New(cache.Config{})
+	defer pages.Stop(true)
+
+	renders := 0
+	handler := cachehttp.Middleware(pages, nil, time.Minute)(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			renders++
+			fmt.Fprint(w, "rendered page")
+		}))
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/page", nil))
+		fmt.Println(rec.Code, rec.Body.String())
+	}
+
+	fmt.Println("Renders:", renders)
+	// Output:
+	// 200 rendered page
+	// 200 rendered page
+	// 200 rendered page
+	// Renders: 1
+}
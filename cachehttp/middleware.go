@@ -0,0 +1,136 @@
+package cachehttp
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"golift.io/cache"
+)
+
+// handlerResponse is what's stored in the cache by the middleware.
+type handlerResponse struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Vary    map[string]string // request header values the response varies on.
+	Expires time.Time         // when the response becomes stale.
+}
+
+// recorder captures a response while it's written to the client.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// Middleware returns an http.Handler middleware that caches response bodies, status
+// codes and headers for GET and HEAD requests. Responses are stored for ttl using the
+// key returned by keyFunc; pass nil to use the request URI. Responses that set cookies,
+// are marked private or no-store, or have status codes that are not cacheable are not
+// stored. A response with a Vary header is only returned to requests with the same
+// values for the varied request headers. Use a cache Namespace if the cache holds other data.
+// Stale responses are never served; enable the cache pruner to remove them from memory.
+func Middleware(
+	store *cache.Cache, keyFunc func(*http.Request) string, ttl time.Duration,
+) func(http.Handler) http.Handler {
+	if keyFunc == nil {
+		keyFunc = func(req *http.Request) string { return req.URL.RequestURI() }
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodGet && req.Method != http.MethodHead {
+				next.ServeHTTP(resp, req)
+				return
+			}
+
+			key := keyFunc(req)
+			if item := store.Get(key); item != nil {
+				if cached, ok := item.Data.(*handlerResponse); ok &&
+					time.Now().Before(cached.Expires) && cached.matches(req) {
+					cached.write(resp, req)
+					return
+				}
+			}
+
+			rec := &recorder{ResponseWriter: resp, status: http.StatusOK}
+			next.ServeHTTP(rec, req)
+
+			if req.Method == http.MethodGet && storable(rec.status, resp.Header()) {
+				expires := time.Now().Add(ttl)
+				store.Save(key, &handlerResponse{
+					Status:  rec.status,
+					Header:  resp.Header().Clone(),
+					Body:    bytes.Clone(rec.body.Bytes()),
+					Vary:    vary(resp.Header(), req),
+					Expires: expires,
+				}, cache.Options{Expire: expires})
+			}
+		})
+	}
+}
+
+// WriteHeader captures the status code.
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write captures the body.
+func (r *recorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data) //nolint:wrapcheck // transparent writer.
+}
+
+// Unwrap allows http.ResponseController to reach the original writer.
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// storable returns true if a handler's response may be cached.
+func storable(status int, header http.Header) bool {
+	return cacheable(status) && header.Get("Set-Cookie") == "" && header.Get("Vary") != "*" &&
+		!hasDirective(header, "no-store") && !hasDirective(header, "private")
+}
+
+// vary returns the request header values named in a response's Vary header.
+func vary(header http.Header, req *http.Request) map[string]string {
+	values := make(map[string]string)
+
+	for _, list := range header.Values("Vary") {
+		for _, name := range strings.Split(list, ",") {
+			if name = http.CanonicalHeaderKey(strings.TrimSpace(name)); name != "" {
+				values[name] = req.Header.Get(name)
+			}
+		}
+	}
+
+	return values
+}
+
+// matches returns true if the request has the same varied header values as the cached response.
+func (h *handlerResponse) matches(req *http.Request) bool {
+	for name, value := range h.Vary {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// write a cached response to a client.
+func (h *handlerResponse) write(resp http.ResponseWriter, req *http.Request) {
+	for name, values := range h.Header {
+		resp.Header()[name] = values
+	}
+
+	resp.Header().Set(HeaderCache, "1")
+	resp.WriteHeader(h.Status)
+
+	if req.Method != http.MethodHead {
+		_, _ = resp.Write(h.Body)
+	}
+}
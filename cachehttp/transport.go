@@ -0,0 +1,315 @@
+// Package cachehttp provides an http.RoundTripper and an http.Handler middleware
+// that store HTTP responses in a golift.io/cache Cache.
+package cachehttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golift.io/cache"
+)
+
+// HeaderCache is added to responses that were returned from cache.
+const HeaderCache = "X-From-Cache"
+
+// Transport is an http.RoundTripper that caches responses to GET and HEAD requests.
+// Responses are cached according to their Cache-Control and Expires headers, and stale
+// responses with an ETag or Last-Modified header are revalidated with conditional requests.
+// Responses with a Vary header are cached for each value of the varied request headers.
+// Responses to requests with an Authorization header are only cached if they're marked
+// public or have an s-maxage, so one user's response is not returned to another user.
+type Transport struct {
+	cache *cache.Cache
+	next  http.RoundTripper
+	ttl   time.Duration
+}
+
+// Option configures a Transport.
+type Option func(*Transport)
+
+// response is what's stored in the cache for each request.
+type response struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Expires time.Time // when the response becomes stale.
+}
+
+// variants is stored at a request's key when the response has a Vary header. Each
+// response is stored at a key that includes the request's values for the varied headers.
+type variants struct {
+	Names []string
+}
+
+// WithTransport sets the round tripper that makes the requests. Default: http.DefaultTransport.
+func WithTransport(next http.RoundTripper) Option {
+	return func(t *Transport) {
+		t.next = next
+	}
+}
+
+// WithDefaultTTL caches responses without freshness headers for ttl.
+// By default, those responses are only cached if they can be revalidated.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(t *Transport) {
+		t.ttl = ttl
+	}
+}
+
+// NewTransport returns an http.RoundTripper that stores responses in the provided cache.
+// Use a cache Namespace if the cache is used for other data too.
+func NewTransport(cache *cache.Cache, opts ...Option) *Transport {
+	transport := &Transport{cache: cache, next: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(transport)
+	}
+
+	return transport
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if (req.Method != http.MethodGet && req.Method != http.MethodHead) ||
+		hasDirective(req.Header, "no-store") || req.Header.Get("Range") != "" {
+		return t.next.RoundTrip(req) //nolint:wrapcheck // transparent proxy.
+	}
+
+	key, cached := t.lookup(req)
+
+	if cached != nil && time.Now().Before(cached.Expires) && !hasDirective(req.Header, "no-cache") {
+		return cached.toResponse(req), nil
+	}
+
+	if cached != nil {
+		req = conditional(req, cached)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("cache transport: %w", err)
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		// Save a new response with the updated headers and expiration. The cached
+		// response may be in use by other requests, so it's never modified.
+		updated := &response{Status: cached.Status, Header: cached.Header.Clone(), Body: cached.Body}
+		for name, values := range resp.Header {
+			updated.Header[name] = values
+		}
+
+		updated.Expires = expires(resp.Header, t.ttl)
+		t.save(key, updated)
+
+		return updated.toResponse(req), nil
+	}
+
+	return t.store(req, resp)
+}
+
+// lookup returns the cache key for a request, and the response cached for it, if any.
+func (t *Transport) lookup(req *http.Request) (string, *response) {
+	key := req.Method + " " + req.URL.String()
+
+	item := t.cache.Get(key)
+	if item == nil {
+		return key, nil
+	}
+
+	if list, ok := item.Data.(*variants); ok {
+		key = variantKey(key, list.Names, req)
+		if item = t.cache.Get(key); item == nil {
+			return key, nil
+		}
+	}
+
+	cached, _ := item.Data.(*response)
+
+	return key, cached
+}
+
+// store saves a response if it's cacheable, and returns a response with an unread body.
+func (t *Transport) store(req *http.Request, resp *http.Response) (*http.Response, error) {
+	if !cacheable(resp.StatusCode) || hasDirective(resp.Header, "no-store") ||
+		hasDirective(resp.Header, "private") || resp.Header.Get("Vary") == "*" ||
+		(req.Header.Get("Authorization") != "" && !hasDirective(resp.Header, "public") &&
+			!hasDirective(resp.Header, "s-maxage")) {
+		return resp, nil
+	}
+
+	stored := &response{Status: resp.StatusCode, Header: resp.Header.Clone(), Expires: expires(resp.Header, t.ttl)}
+	if !time.Now().Before(stored.Expires) && !revalidates(resp.Header) {
+		return resp, nil // not fresh and can't be revalidated; don't waste memory on it.
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("cache transport: reading body: %w", err)
+	}
+
+	stored.Body = body
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	key := req.Method + " " + req.URL.String()
+
+	if names := varyNames(resp.Header); len(names) > 0 {
+		t.cache.Save(key, &variants{Names: names}, cache.Options{Prune: true})
+		key = variantKey(key, names, req)
+	}
+
+	t.save(key, stored)
+
+	return resp, nil
+}
+
+// varyNames returns the sorted, canonical header names in a response's Vary header.
+func varyNames(header http.Header) []string {
+	var names []string
+
+	for _, list := range header.Values("Vary") {
+		for _, name := range strings.Split(list, ",") {
+			if name = http.CanonicalHeaderKey(strings.TrimSpace(name)); name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// variantKey returns the key for a response that varies on the named request headers.
+func variantKey(key string, names []string, req *http.Request) string {
+	var buf strings.Builder
+
+	buf.WriteString(key)
+
+	for _, name := range names {
+		buf.WriteString("\n" + name + ": " + strings.Join(req.Header.Values(name), ", "))
+	}
+
+	return buf.String()
+}
+
+func (t *Transport) save(key string, stored *response) {
+	opts := cache.Options{Prune: true}
+	if !revalidates(stored.Header) {
+		opts.Expire = stored.Expires // keep revalidatable responses until they're pruned.
+	}
+
+	t.cache.Save(key, stored, opts)
+}
+
+// toResponse makes a new http.Response from a cached response.
+func (r *response) toResponse(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	header.Set(HeaderCache, "1")
+
+	return &http.Response{
+		Status:        strconv.Itoa(r.Status) + " " + http.StatusText(r.Status),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// conditional returns a copy of the request with headers to revalidate a cached response.
+func conditional(req *http.Request, cached *response) *http.Request {
+	etag, modified := cached.Header.Get("ETag"), cached.Header.Get("Last-Modified")
+	if etag == "" && modified == "" {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	if modified != "" {
+		req.Header.Set("If-Modified-Since", modified)
+	}
+
+	return req
+}
+
+// expires returns the time a response becomes stale from its headers, or now + ttl if
+// it does not have freshness headers. A response that must be revalidated is stale now.
+func expires(header http.Header, ttl time.Duration) time.Time {
+	now := time.Now()
+
+	if hasDirective(header, "no-cache") {
+		return now
+	}
+
+	for _, directive := range directives(header) {
+		if age, ok := strings.CutPrefix(directive, "max-age="); ok {
+			if seconds, err := strconv.Atoi(age); err == nil {
+				return now.Add(time.Duration(seconds) * time.Second)
+			}
+		}
+	}
+
+	if expire, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return expire
+	}
+
+	return now.Add(ttl)
+}
+
+// revalidates returns true if the response can be revalidated with a conditional request.
+func revalidates(header http.Header) bool {
+	return header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+}
+
+// cacheable returns true for status codes that may be cached by default (RFC 9110 15.1).
+func cacheable(status int) bool {
+	switch status {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusPermanentRedirect,
+		http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusGone,
+		http.StatusRequestURITooLong, http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// directives returns the lower-cased Cache-Control directives in a header.
+func directives(header http.Header) []string {
+	var list []string
+
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			if directive = strings.ToLower(strings.TrimSpace(directive)); directive != "" {
+				list = append(list, directive)
+			}
+		}
+	}
+
+	return list
+}
+
+// hasDirective returns true if a Cache-Control directive is present in a header.
+func hasDirective(header http.Header, name string) bool {
+	for _, directive := range directives(header) {
+		if directive == name || strings.HasPrefix(directive, name+"=") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,121 @@
+// Package cacheinvalidate keeps the caches in replicas of an app from serving stale data.
+// Each replica wraps its cache with an Invalidator, and deletes and saves through it.
+// Deleted, flushed and saved keys are broadcast to the other replicas with a Transport,
+// like Redis pub/sub (in golift.io/cache/cacheredis) or NATS (in golift.io/cache/cachenats),
+// and the other replicas delete them, so their next Get loads the new data.
+//
+// Only keys are broadcast, never data, and delivery is best-effort: a replica that's not
+// subscribed when a message is published keeps its stale items until they're pruned.
+// Pair this with Expire or MaxUnused to bound how long that can last.
+package cacheinvalidate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"golift.io/cache"
+)
+
+// Transport publishes messages to every replica. Implementations must be safe for concurrent use.
+//   - Publish sends a message to every subscribed replica, including this one.
+//   - Subscribe calls handle with every message published by any replica, one at a time,
+//     until ctx is done. It returns nil when ctx is done, or an error if the subscription fails.
+type Transport interface {
+	Publish(ctx context.Context, message []byte) error
+	Subscribe(ctx context.Context, handle func(message []byte)) error
+}
+
+// Message is broadcast to the other replicas. It's encoded as JSON.
+type Message struct {
+	// Origin identifies the replica that sent the message, so it does not apply its own messages.
+	Origin string `json:"origin"`
+	// Keys are deleted from every other replica.
+	Keys []string `json:"keys,omitempty"`
+	// Flush deletes every key from every other replica.
+	Flush bool `json:"flush,omitempty"`
+}
+
+// Invalidator deletes keys from a cache, and from the caches in the other replicas.
+// Create one with New, and call Run to apply the other replicas' messages.
+type Invalidator struct {
+	cache     *cache.Cache
+	transport Transport
+	origin    string
+}
+
+// New returns an Invalidator for a cache, or a cache namespace. Every replica must use
+// the same namespace and Transport channel, or subject, for the same data.
+func New(cache *cache.Cache, transport Transport) *Invalidator {
+	origin := make([]byte, 8) //nolint:mnd // 64 random bits.
+	_, _ = rand.Read(origin)
+
+	return &Invalidator{cache: cache, transport: transport, origin: hex.EncodeToString(origin)}
+}
+
+// Run applies the messages from the other replicas until ctx is done.
+// It returns the Transport's error if the subscription fails; call it again to resubscribe.
+func (i *Invalidator) Run(ctx context.Context) error {
+	err := i.transport.Subscribe(ctx, func(data []byte) {
+		var msg Message
+		if json.Unmarshal(data, &msg) != nil || msg.Origin == i.origin {
+			return // not an invalidation message, or this replica sent it.
+		}
+
+		if msg.Flush {
+			i.cache.Flush()
+		}
+
+		for _, key := range msg.Keys {
+			i.cache.Delete(key)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("subscribing to invalidations: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes keys from this cache, and from the other replicas.
+// The keys are deleted here even if publishing returns an error.
+func (i *Invalidator) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		i.cache.Delete(key)
+	}
+
+	return i.publish(ctx, Message{Keys: keys})
+}
+
+// Flush deletes every key from this cache, and from the other replicas.
+func (i *Invalidator) Flush(ctx context.Context) error {
+	i.cache.Flush()
+
+	return i.publish(ctx, Message{Flush: true})
+}
+
+// Save saves an item in this cache, and deletes the key from the other replicas,
+// so they do not keep serving the old data. The item is saved here even if
+// publishing returns an error.
+func (i *Invalidator) Save(ctx context.Context, key string, data any, opts cache.Options) error {
+	i.cache.Save(key, data, opts)
+
+	return i.publish(ctx, Message{Keys: []string{key}})
+}
+
+func (i *Invalidator) publish(ctx context.Context, msg Message) error {
+	msg.Origin = i.origin
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encoding invalidation: %w", err)
+	}
+
+	if err := i.transport.Publish(ctx, data); err != nil {
+		return fmt.Errorf("publishing invalidation: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package cacheinvalidate_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golift.io/cache"
+	"golift.io/cache/cacheinvalidate"
+)
+
+// hub is an in-memory Transport for examples and tests. Publish waits until every
+// subscriber handled the message, so the examples are deterministic.
+type hub struct {
+	mu   sync.Mutex
+	subs []func([]byte)
+	wait sync.WaitGroup
+}
+
+func (h *hub) Publish(_ context.Context, message []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, handle := range h.subs {
+		handle(message)
+	}
+
+	return nil
+}
+
+func (h *hub) Subscribe(ctx context.Context, handle func([]byte)) error {
+	h.mu.Lock()
+	h.subs = append(h.subs, handle)
+	h.mu.Unlock()
+	h.wait.Done()
+
+	<-ctx.Done()
+
+	return nil
+}
+
+func ExampleNew() {
+	transport := &hub{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two replicas of an app, each with its own cache.
+	first := cache.New(cache.Config{})
+	defer first.Stop(true)
+
+	second := cache.New(cache.Config{})
+	defer second.Stop(true)
+
+	firstInv := cacheinvalidate.New(first, transport)
+	secondInv := cacheinvalidate.New(second, transport)
+
+	transport.wait.Add(2)
+
+	go firstInv.Run(ctx)  //nolint:errcheck // the hub never fails.
+	go secondInv.Run(ctx) //nolint:errcheck
+
+	transport.wait.Wait()
+
+	first.Save("admin", "Super Dooper", cache.Options{})
+	second.Save("admin", "Super Dooper", cache.Options{})
+
+	// The first replica changes the data, and the second one drops its stale copy.
+	err := firstInv.Save(ctx, "admin", "Super Duper", cache.Options{})
+	fmt.Println(err, first.Get("admin").Data, second.Get("admin"))
+
+	second.Save("guest", "Nobody", cache.Options{})
+	_ = secondInv.Flush(ctx)
+	fmt.Println(first.Keys(), second.Keys())
+	// Output:
+	// <nil> Super Duper <nil>
+	// [] []
+}
@@ -0,0 +1,331 @@
+// Package cachememcached provides a server that speaks the memcached text protocol,
+// backed by a golift.io/cache Cache. Embed it in a Go daemon so memcached clients
+// written in other languages can share the daemon's cache.
+//
+// Supported commands: get, set, add, replace, delete, incr, decr, flush_all, version and quit.
+// Values are stored as []byte. Values saved with non-zero flags are stored as a Value, so the
+// flags are returned to the client. Cached strings and []byte saved by Go code are served too.
+// Like memcached, incr and decr keep the item's expiration time.
+package cachememcached
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golift.io/cache"
+)
+
+// MaxValueSize is the largest value a client may set; the memcached default is 1MB.
+const MaxValueSize = 1 << 20
+
+// relativeExpire is the largest exptime treated as seconds from now, like memcached.
+// Larger values are unix timestamps.
+const relativeExpire = 60 * 60 * 24 * 30
+
+// Value is stored in the cache for items set with non-zero flags.
+type Value struct {
+	Flags uint32
+	Data  []byte
+}
+
+// Server is a memcached text protocol server.
+type Server struct {
+	cache *cache.Cache
+}
+
+// errClient is returned when a client sends a malformed command.
+var errClient = errors.New("bad command line format")
+
+// New returns a memcached server that stores items in the provided cache.
+// Use a cache Namespace if the cache is used for other data too.
+func New(cache *cache.Cache) *Server {
+	return &Server{cache: cache}
+}
+
+// ListenAndServe listens on the TCP network address addr and serves memcached clients.
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening: %w", err)
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on the listener and serves each in a go routine.
+// This returns when the listener is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return fmt.Errorf("accepting connection: %w", err)
+		}
+
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serves a single client connection until it sends quit or disconnects.
+// The connection is closed when this returns.
+func (s *Server) ServeConn(conn io.ReadWriteCloser) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "quit" {
+			return
+		}
+
+		if err := s.command(reader, writer, fields); err != nil {
+			return
+		}
+
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// command runs one command and writes the reply. An error means the connection is broken.
+func (s *Server) command(reader *bufio.Reader, writer *bufio.Writer, fields []string) error {
+	var (
+		reply string
+		err   error
+	)
+
+	switch cmd, args := fields[0], fields[1:]; cmd {
+	case "get":
+		return s.get(writer, args)
+	case "set", "add", "replace":
+		if reply, err = s.store(reader, cmd, args); err != nil {
+			return err
+		}
+	case "delete":
+		reply = s.delete(args)
+	case "incr", "decr":
+		reply = s.incr(cmd == "decr", args)
+	case "flush_all":
+		s.cache.Flush()
+		reply = "OK"
+	case "version":
+		reply = "VERSION golift.io/cache"
+	default:
+		reply = "ERROR"
+	}
+
+	if noReply(fields) {
+		return nil
+	}
+
+	_, err = writer.WriteString(reply + "\r\n")
+
+	return err //nolint:wrapcheck // the connection is closed on error.
+}
+
+func (s *Server) get(writer *bufio.Writer, keys []string) error {
+	for _, key := range keys {
+		item := s.cache.Get(key)
+		if item == nil {
+			continue
+		}
+
+		flags, data, ok := decode(item.Data)
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(writer, "VALUE %s %d %d\r\n", key, flags, len(data))
+		writer.Write(data) //nolint:errcheck // checked on Flush.
+		writer.WriteString("\r\n")
+	}
+
+	_, err := writer.WriteString("END\r\n")
+
+	return err //nolint:wrapcheck // the connection is closed on error.
+}
+
+// store runs set, add and replace: <cmd> <key> <flags> <exptime> <bytes> [noreply].
+// The value is read from the client, and the reply is returned.
+func (s *Server) store(reader *bufio.Reader, cmd string, args []string) (string, error) {
+	if len(args) < 4 { //nolint:mnd // key, flags, exptime and bytes.
+		return "ERROR", nil
+	}
+
+	size, err := strconv.Atoi(args[3])
+	if err != nil || size < 0 {
+		return "CLIENT_ERROR " + errClient.Error(), nil
+	}
+
+	if size > MaxValueSize {
+		// Read and discard the value, so the next command can be read.
+		_, err = io.CopyN(io.Discard, reader, int64(size)+2) //nolint:mnd // \r\n
+
+		return "SERVER_ERROR object too large for cache", err //nolint:wrapcheck // the connection is closed.
+	}
+
+	data := make([]byte, size+2) //nolint:mnd // \r\n
+	if _, err = io.ReadFull(reader, data); err != nil {
+		return "", err //nolint:wrapcheck // the connection is closed.
+	}
+
+	return s.save(cmd, args, data[:size]), nil
+}
+
+// save stores a value that was read from the client and returns the reply.
+func (s *Server) save(cmd string, args []string, data []byte) string {
+	flags, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		return "CLIENT_ERROR " + errClient.Error()
+	}
+
+	exptime, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR " + errClient.Error()
+	}
+
+	var (
+		key   = args[0]
+		opts  = cache.Options{Expire: expire(exptime)}
+		value any
+	)
+
+	if value = data; flags != 0 {
+		value = &Value{Flags: uint32(flags), Data: data}
+	}
+
+	switch {
+	case exptime < 0:
+		// A negative exptime means the item is immediately expired.
+		if cmd == "add" && s.cache.Get(key) != nil || cmd == "replace" && s.cache.Get(key) == nil {
+			return "NOT_STORED"
+		}
+
+		s.cache.Delete(key)
+	case cmd == "add":
+		if !s.cache.CompareAndSwap(key, nil, value, opts) {
+			return "NOT_STORED"
+		}
+	case cmd == "replace":
+		if !s.cache.Replace(key, value, opts) {
+			return "NOT_STORED"
+		}
+	default:
+		s.cache.Save(key, value, opts)
+	}
+
+	return "STORED"
+}
+
+// delete runs: delete <key> [noreply].
+func (s *Server) delete(args []string) string {
+	switch {
+	case len(args) == 0:
+		return "ERROR"
+	case s.cache.Delete(args[0]):
+		return "DELETED"
+	default:
+		return "NOT_FOUND"
+	}
+}
+
+// incr runs incr and decr: <cmd> <key> <value> [noreply].
+// The item's expiration, pin and meta are kept.
+func (s *Server) incr(decr bool, args []string) string {
+	if len(args) < 2 { //nolint:mnd // key and value.
+		return "ERROR"
+	}
+
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR invalid numeric delta argument"
+	}
+
+	var reply string
+
+	_ = s.cache.Txn(func(tx *cache.Txn) error {
+		item := tx.Get(args[0])
+		if item == nil {
+			reply = "NOT_FOUND"
+			return nil
+		}
+
+		flags, data, ok := decode(item.Data)
+
+		current, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if !ok || err != nil {
+			reply = "CLIENT_ERROR cannot increment or decrement non-numeric value"
+			return nil
+		}
+
+		switch {
+		case !decr:
+			current += delta // wraps at 64 bits, like memcached.
+		case delta > current:
+			current = 0 // decr never goes below 0.
+		default:
+			current -= delta
+		}
+
+		reply = strconv.FormatUint(current, 10)
+		opts := cache.Options{Expire: item.Expires, Pin: item.Pinned, Meta: item.Meta}
+
+		if flags != 0 {
+			tx.Save(args[0], &Value{Flags: flags, Data: []byte(reply)}, opts)
+		} else {
+			tx.Save(args[0], []byte(reply), opts)
+		}
+
+		return nil
+	})
+
+	return reply
+}
+
+// decode returns the flags and bytes for cached data, and false if it's not a type we can serve.
+func decode(data any) (uint32, []byte, bool) {
+	switch data := data.(type) {
+	case *Value:
+		return data.Flags, data.Data, true
+	case []byte:
+		return 0, data, true
+	case string:
+		return 0, []byte(data), true
+	default:
+		return 0, nil, false
+	}
+}
+
+// expire turns a memcached exptime into an Expire time for the cache.
+func expire(exptime int64) time.Time {
+	switch {
+	case exptime <= 0:
+		return time.Time{}
+	case exptime <= relativeExpire:
+		return time.Now().Add(time.Duration(exptime) * time.Second)
+	default:
+		return time.Unix(exptime, 0)
+	}
+}
+
+// noReply returns true if the last argument of a command is noreply.
+func noReply(fields []string) bool {
+	return len(fields) > 1 && fields[len(fields)-1] == "noreply"
+}
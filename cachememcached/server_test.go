@@ -0,0 +1,88 @@
+package cachememcached_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golift.io/cache"
+	"golift.io/cache/cachememcached"
+)
+
+func ExampleServer_ServeConn() {
+	shared := cache.New(cache.Config{})
+	defer shared.Stop(true)
+
+	client, conn := net.Pipe()
+	defer client.Close()
+
+	go cachememcached.New(shared).ServeConn(conn)
+
+	reader := bufio.NewReader(client)
+	// Send a command and print the reply lines until the last one.
+	send := func(command, last string) {
+		fmt.Fprint(client, command)
+
+		for {
+			line, err := reader.ReadString('\n')
+			fmt.Println(strings.TrimSpace(line))
+
+			if err != nil || strings.HasPrefix(line, last) {
+				return
+			}
+		}
+	}
+
+	send("set counter 0 0 1\r\n5\r\n", "STORED")
+	send("incr counter 10\r\n", "15")
+	send("get counter missing\r\n", "END")
+	fmt.Println("Go sees:", string(shared.Get("counter").Data.([]byte)))
+	send("delete counter\r\n", "DELETED")
+	// Output:
+	// STORED
+	// 15
+	// VALUE counter 0 2
+	// 15
+	// END
+	// Go sees: 15
+	// DELETED
+}
+
+func TestIncrKeepsExpire(t *testing.T) {
+	t.Parallel()
+
+	shared := cache.New(cache.Config{})
+	t.Cleanup(func() { shared.Stop(true) })
+
+	client, conn := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+
+	go cachememcached.New(shared).ServeConn(conn)
+
+	reader := bufio.NewReader(client)
+	send := func(command, expect string) {
+		t.Helper()
+		fmt.Fprint(client, command)
+
+		if line, _ := reader.ReadString('\n'); strings.TrimSpace(line) != expect {
+			t.Fatalf("%q replied %q, expected %q", command, line, expect)
+		}
+	}
+
+	send("set counter 0 100 1\r\n5\r\n", "STORED")
+	expires := shared.Get("counter").Expires
+
+	send("incr counter 10\r\n", "15")
+	send("decr counter 3\r\n", "12")
+
+	if got := shared.Get("counter").Expires; expires.IsZero() || !got.Equal(expires) {
+		t.Errorf("incr and decr changed the expiration from %v to %v", expires, got)
+	}
+
+	if time.Until(expires) > 100*time.Second {
+		t.Errorf("the expiration is more than 100 seconds away: %v", expires)
+	}
+}
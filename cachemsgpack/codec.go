@@ -0,0 +1,38 @@
+// Package cachemsgpack provides a MessagePack cache.Codec for golift.io/cache Export and Import.
+// MessagePack is smaller and faster to encode than JSON, and unlike gob, it can be read by
+// other languages. Field names match the JSON export, like "key", "data" and "options".
+//
+// This package is its own Go module, so the cache module does not depend on a msgpack library.
+package cachemsgpack
+
+import (
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"golift.io/cache"
+)
+
+// Codec is a cache.Codec that uses MessagePack. Imported Data has the types msgpack
+// decodes into an interface: integers, float64, string, []byte, bool, time.Time,
+// []any and map[string]any.
+type Codec struct{}
+
+// Make sure Codec satisfies the interface.
+var _ cache.Codec = Codec{}
+
+// Encode writes v as MessagePack.
+func (Codec) Encode(w io.Writer, v any) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+
+	return encoder.Encode(v) //nolint:wrapcheck // Export wraps it.
+}
+
+// Decode reads v from MessagePack.
+func (Codec) Decode(r io.Reader, v any) error {
+	decoder := msgpack.NewDecoder(r)
+	decoder.SetCustomStructTag("json")
+
+	return decoder.Decode(v) //nolint:wrapcheck // Import wraps it.
+}
@@ -0,0 +1,34 @@
+package cachemsgpack_test
+
+import (
+	"bytes"
+	"fmt"
+
+	"golift.io/cache"
+	"golift.io/cache/cachemsgpack"
+)
+
+func ExampleCodec() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{Prune: true, Meta: map[string]string{"origin": "ldap"}})
+	users.Save("count", 42, cache.Options{})
+
+	var snapshot bytes.Buffer
+	if err := users.Export(&snapshot, cachemsgpack.Codec{}); err != nil {
+		panic(err)
+	}
+
+	restored := cache.New(cache.Config{})
+	defer restored.Stop(true)
+
+	err := restored.Import(&snapshot, cachemsgpack.Codec{})
+	admin := restored.Get("admin")
+
+	fmt.Println(err, admin.Data, admin.Prunable, admin.Meta)
+	fmt.Printf("%v %T\n", restored.Get("count").Data, restored.Get("count").Data)
+	// Output:
+	// <nil> Super Dooper true map[origin:ldap]
+	// 42 int8
+}
@@ -0,0 +1,12 @@
+module golift.io/cache/cachemsgpack
+
+go 1.21
+
+require (
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	golift.io/cache v0.0.0
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+
+replace golift.io/cache => ../
@@ -0,0 +1,53 @@
+// Package cachenats provides a cacheinvalidate.Transport that sends messages with NATS,
+// so replicas of an app can delete stale keys from each other's golift.io/cache caches.
+//
+// This package is its own Go module, so the cache module does not depend on a NATS client.
+package cachenats
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"golift.io/cache/cacheinvalidate"
+)
+
+// Transport is a cacheinvalidate.Transport that sends messages on a NATS Subject.
+// Every replica must use the same Subject.
+type Transport struct {
+	Conn    *nats.Conn
+	Subject string
+}
+
+// Make sure Transport satisfies the interface.
+var _ cacheinvalidate.Transport = (*Transport)(nil)
+
+// Publish sends a message to every replica subscribed to the Subject.
+func (t *Transport) Publish(_ context.Context, message []byte) error {
+	if err := t.Conn.Publish(t.Subject, message); err != nil {
+		return fmt.Errorf("publishing to nats: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe calls handle with every message sent to the Subject until ctx is done.
+func (t *Transport) Subscribe(ctx context.Context, handle func(message []byte)) error {
+	sub, err := t.Conn.Subscribe(t.Subject, func(msg *nats.Msg) { handle(msg.Data) })
+	if err != nil {
+		return fmt.Errorf("subscribing to nats: %w", err)
+	}
+
+	if err := t.Conn.Flush(); err != nil { // wait for the server to add the subscription.
+		_ = sub.Unsubscribe()
+		return fmt.Errorf("subscribing to nats: %w", err)
+	}
+
+	<-ctx.Done()
+
+	if err := sub.Unsubscribe(); err != nil && t.Conn.IsConnected() {
+		return fmt.Errorf("unsubscribing from nats: %w", err)
+	}
+
+	return nil
+}
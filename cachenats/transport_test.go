@@ -0,0 +1,77 @@
+package cachenats_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"golift.io/cache"
+	"golift.io/cache/cacheinvalidate"
+	"golift.io/cache/cachenats"
+)
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	opts := natstest.DefaultTestOptions
+	opts.Port = server.RANDOM_PORT
+	srv := natstest.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	first, second := cache.New(cache.Config{}), cache.New(cache.Config{})
+	t.Cleanup(func() { first.Stop(true); second.Stop(true) })
+
+	firstInv := cacheinvalidate.New(first, transport(t, srv.ClientURL()))
+	secondInv := cacheinvalidate.New(second, transport(t, srv.ClientURL()))
+
+	subscribed := srv.NumSubscriptions() + 2
+
+	go firstInv.Run(ctx)  //nolint:errcheck // the test fails if it does not run.
+	go secondInv.Run(ctx) //nolint:errcheck
+
+	waitFor(t, func() bool { return srv.NumSubscriptions() == subscribed })
+
+	first.Save("admin", "Super Dooper", cache.Options{})
+	second.Save("admin", "Super Dooper", cache.Options{})
+
+	if err := secondInv.Save(ctx, "admin", "Super Duper", cache.Options{}); err != nil {
+		t.Fatalf("saving: %v", err)
+	}
+
+	waitFor(t, func() bool { return first.Get("admin") == nil })
+
+	if item := second.Get("admin"); item == nil || item.Data != "Super Duper" {
+		t.Fatalf("the replica that saved the key lost it: %v", item)
+	}
+}
+
+// transport connects to NATS for one replica.
+func transport(t *testing.T, url string) *cachenats.Transport {
+	t.Helper()
+
+	conn, err := nats.Connect(url)
+	if err != nil {
+		t.Fatalf("connecting to nats: %v", err)
+	}
+
+	t.Cleanup(conn.Close)
+
+	return &cachenats.Transport{Conn: conn, Subject: "cache.invalidations"}
+}
+
+// waitFor fails the test if ready does not return true within a few seconds.
+func waitFor(t *testing.T, ready func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); !ready(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package cacheotel provides OpenTelemetry instrumentation for a golift.io/cache Cache.
+// Register metrics with RegisterMetrics to include the cache in an existing OTLP pipeline,
+// and wrap the cache with NewTraced to see cache requests in distributed traces.
+//
+// This package is its own Go module, so the cache module does not depend on OpenTelemetry.
+package cacheotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golift.io/cache"
+)
+
+// Result attribute values for the cache.gets counter.
+var (
+	resultHit  = attribute.String("result", "hit")  //nolint:gochecknoglobals // reused attribute.
+	resultMiss = attribute.String("result", "miss") //nolint:gochecknoglobals // reused attribute.
+)
+
+// RegisterMetrics registers observable instruments for the cache with the meter.
+// The instruments are read from cache.Stats() every time the meter is collected:
+//   - cache.size: gauge, number of items in the cache.
+//   - cache.gets: counter, gets with a result attribute of hit or miss.
+//   - cache.saves, cache.updates, cache.deletes, cache.pruned: counters.
+//   - cache.hit_ratio: gauge, hits divided by gets since the cache started.
+//   - cache.prune.duration: counter, seconds spent pruning.
+//   - cache.request.latency: gauge, seconds the stats request waited for the cache processor.
+//
+// Pass attributes, like the cache name, to tell multiple caches apart. Call Unregister
+// on the returned registration before stopping the cache; collecting metrics for a
+// stopped cache produces a panic.
+func RegisterMetrics(meter metric.Meter, cache *cache.Cache, attrs ...attribute.KeyValue) (metric.Registration, error) {
+	var (
+		inst metrics
+		err  error
+	)
+
+	if err = inst.create(meter); err != nil {
+		return nil, err
+	}
+
+	set := attribute.NewSet(attrs...)
+	hit := attribute.NewSet(append(attrs, resultHit)...)
+	miss := attribute.NewSet(append(attrs, resultMiss)...)
+
+	reg, err := meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		start := time.Now()
+		stats := cache.Stats()
+		latency := time.Since(start)
+		opt := metric.WithAttributeSet(set)
+
+		observer.ObserveInt64(inst.size, stats.Size, opt)
+		observer.ObserveInt64(inst.gets, stats.Hits, metric.WithAttributeSet(hit))
+		observer.ObserveInt64(inst.gets, stats.Misses, metric.WithAttributeSet(miss))
+		observer.ObserveInt64(inst.saves, stats.Saves, opt)
+		observer.ObserveInt64(inst.updates, stats.Updates, opt)
+		observer.ObserveInt64(inst.deletes, stats.Deletes, opt)
+		observer.ObserveInt64(inst.pruned, stats.Pruned, opt)
+		observer.ObserveFloat64(inst.pruning, stats.Pruning.Seconds(), opt)
+		observer.ObserveFloat64(inst.latency, latency.Seconds(), opt)
+
+		if stats.Gets > 0 {
+			observer.ObserveFloat64(inst.ratio, float64(stats.Hits)/float64(stats.Gets), opt)
+		}
+
+		return nil
+	}, inst.size, inst.gets, inst.saves, inst.updates, inst.deletes, inst.pruned, inst.pruning, inst.latency, inst.ratio)
+	if err != nil {
+		return nil, fmt.Errorf("registering cache metrics callback: %w", err)
+	}
+
+	return reg, nil
+}
+
+// metrics holds the observable instruments for a cache.
+type metrics struct {
+	size    metric.Int64ObservableGauge
+	gets    metric.Int64ObservableCounter
+	saves   metric.Int64ObservableCounter
+	updates metric.Int64ObservableCounter
+	deletes metric.Int64ObservableCounter
+	pruned  metric.Int64ObservableCounter
+	pruning metric.Float64ObservableCounter
+	latency metric.Float64ObservableGauge
+	ratio   metric.Float64ObservableGauge
+}
+
+func (m *metrics) create(meter metric.Meter) error {
+	var err error
+
+	counter := func(name, desc string) metric.Int64ObservableCounter {
+		if err != nil {
+			return nil
+		}
+
+		var inst metric.Int64ObservableCounter
+		inst, err = meter.Int64ObservableCounter(name, metric.WithDescription(desc), metric.WithUnit("{item}"))
+
+		return inst
+	}
+
+	m.gets = counter("cache.gets", "Cache gets, by result.")
+	m.saves = counter("cache.saves", "Saves for a new key.")
+	m.updates = counter("cache.updates", "Saves that updated an existing key.")
+	m.deletes = counter("cache.deletes", "Deleted keys.")
+	m.pruned = counter("cache.pruned", "Items removed by the pruner.")
+
+	if err != nil {
+		return fmt.Errorf("creating cache metrics: %w", err)
+	}
+
+	if m.size, err = meter.Int64ObservableGauge("cache.size",
+		metric.WithDescription("Items in the cache."), metric.WithUnit("{item}")); err != nil {
+		return fmt.Errorf("creating cache metrics: %w", err)
+	}
+
+	if m.pruning, err = meter.Float64ObservableCounter("cache.prune.duration",
+		metric.WithDescription("Time spent pruning."), metric.WithUnit("s")); err != nil {
+		return fmt.Errorf("creating cache metrics: %w", err)
+	}
+
+	if m.latency, err = meter.Float64ObservableGauge("cache.request.latency",
+		metric.WithDescription("Time a request waited for the cache processor."), metric.WithUnit("s")); err != nil {
+		return fmt.Errorf("creating cache metrics: %w", err)
+	}
+
+	if m.ratio, err = meter.Float64ObservableGauge("cache.hit_ratio",
+		metric.WithDescription("Hits divided by gets."), metric.WithUnit("1")); err != nil {
+		return fmt.Errorf("creating cache metrics: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package cacheotel_test
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"golift.io/cache"
+	"golift.io/cache/cacheotel"
+)
+
+func ExampleRegisterMetrics() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("example")
+
+	reg, err := cacheotel.RegisterMetrics(meter, users, attribute.String("cache", "users"))
+	if err != nil {
+		panic(err)
+	}
+	defer reg.Unregister() //nolint:errcheck // runs before Stop.
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Get("admin")
+	users.Get("admin")
+	users.Get("luser")
+
+	var collected metricdata.ResourceMetrics
+	_ = reader.Collect(context.Background(), &collected)
+
+	for _, metric := range collected.ScopeMetrics[0].Metrics {
+		switch data := metric.Data.(type) {
+		case metricdata.Gauge[int64]:
+			fmt.Println(metric.Name, data.DataPoints[0].Value)
+		case metricdata.Gauge[float64]:
+			if metric.Name == "cache.hit_ratio" {
+				fmt.Printf("%s %.2f\n", metric.Name, data.DataPoints[0].Value)
+			}
+		case metricdata.Sum[int64]:
+			for _, point := range data.DataPoints {
+				result, _ := point.Attributes.Value("result")
+				fmt.Println(metric.Name, result.AsString(), point.Value)
+			}
+		}
+	}
+	// Unordered output:
+	// cache.gets hit 2
+	// cache.gets miss 1
+	// cache.saves  1
+	// cache.updates  0
+	// cache.deletes  0
+	// cache.pruned  0
+	// cache.size 1
+	// cache.hit_ratio 0.67
+}
@@ -0,0 +1,103 @@
+package cacheotel
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golift.io/cache"
+)
+
+// Span attribute keys.
+const (
+	// AttrKeyHash is a hash of the cache key. Keys are hashed because they may be sensitive.
+	AttrKeyHash = attribute.Key("cache.key_hash")
+	// AttrHit is true if a Get found the key.
+	AttrHit = attribute.Key("cache.hit")
+	// AttrExisted is true if the key existed before a Save or Delete.
+	AttrExisted = attribute.Key("cache.existed")
+	// AttrWait is how long the request took in the cache processor, in seconds.
+	// This is mostly time spent waiting for other requests to finish.
+	AttrWait = attribute.Key("cache.wait")
+)
+
+// Traced wraps a cache and creates a span for each Get, Save and Delete.
+// Use it in place of the cache where requests have a context.
+type Traced struct {
+	cache  *cache.Cache
+	tracer trace.Tracer
+}
+
+// NewTraced returns a cache wrapper that creates spans with the tracer.
+// If tracer is nil, the wrapper creates no spans and calls the cache directly.
+func NewTraced(cache *cache.Cache, tracer trace.Tracer) *Traced {
+	return &Traced{cache: cache, tracer: tracer}
+}
+
+// Cache returns the wrapped cache.
+func (t *Traced) Cache() *cache.Cache {
+	return t.cache
+}
+
+// Get calls cache.Get() inside a span.
+func (t *Traced) Get(ctx context.Context, key string) *cache.Item {
+	var item *cache.Item
+
+	t.trace(ctx, "cache.Get", key, func() attribute.KeyValue {
+		item = t.cache.Get(key)
+		return AttrHit.Bool(item != nil)
+	})
+
+	return item
+}
+
+// Save calls cache.Save() inside a span.
+func (t *Traced) Save(ctx context.Context, key string, data any, opts cache.Options) bool {
+	var existed bool
+
+	t.trace(ctx, "cache.Save", key, func() attribute.KeyValue {
+		existed = t.cache.Save(key, data, opts)
+		return AttrExisted.Bool(existed)
+	})
+
+	return existed
+}
+
+// Delete calls cache.Delete() inside a span.
+func (t *Traced) Delete(ctx context.Context, key string) bool {
+	var existed bool
+
+	t.trace(ctx, "cache.Delete", key, func() attribute.KeyValue {
+		existed = t.cache.Delete(key)
+		return AttrExisted.Bool(existed)
+	})
+
+	return existed
+}
+
+// trace runs a cache request inside a span, and adds the attribute it returns to the span.
+func (t *Traced) trace(ctx context.Context, name, key string, request func() attribute.KeyValue) {
+	if t.tracer == nil {
+		request()
+		return
+	}
+
+	_, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(AttrKeyHash.String(hashKey(key))))
+	defer span.End()
+
+	start := time.Now()
+	result := request()
+	span.SetAttributes(result, AttrWait.Float64(time.Since(start).Seconds()))
+}
+
+// hashKey returns a short, stable hash of a key.
+func hashKey(key string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+
+	return strconv.FormatUint(hash.Sum64(), 16) //nolint:mnd // hexadecimal.
+}
@@ -0,0 +1,43 @@
+package cacheotel_test
+
+import (
+	"context"
+	"fmt"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golift.io/cache"
+	"golift.io/cache/cacheotel"
+)
+
+func ExampleNewTraced() {
+	users := cache.New(cache.Config{})
+	defer users.Stop(true)
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("example")
+	traced := cacheotel.NewTraced(users, tracer)
+
+	ctx := context.Background()
+	traced.Save(ctx, "admin", "Super Dooper", cache.Options{})
+	traced.Get(ctx, "admin")
+	traced.Get(ctx, "luser")
+	traced.Delete(ctx, "admin")
+
+	for _, span := range recorder.Ended() {
+		fmt.Print(span.Name())
+
+		for _, attr := range span.Attributes() {
+			if attr.Key == cacheotel.AttrHit || attr.Key == cacheotel.AttrExisted {
+				fmt.Print(" ", attr.Key, "=", attr.Value.Emit())
+			}
+		}
+
+		fmt.Println()
+	}
+	// Output:
+	// cache.Save cache.existed=false
+	// cache.Get cache.hit=true
+	// cache.Get cache.hit=false
+	// cache.Delete cache.existed=true
+}
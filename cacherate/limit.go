@@ -0,0 +1,161 @@
+// Package cacherate limits the rate of requests by key, like an IP address or a user ID,
+// with counters stored in a golift.io/cache Cache. Counters expire with the cache's
+// per-item expiry, so keys that stop making requests do not keep using memory.
+//
+// Window counts requests in a sliding window with Cache.Increment. Bucket is a token bucket
+// updated with Cache.Compute; it allows bursts, and refills at a steady rate. Both share a
+// cache safely with other data if they're given a Namespace.
+package cacherate
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"golift.io/cache"
+)
+
+// Config sets the rate limit: Limit requests per Window.
+type Config struct {
+	// Limit is the number of requests allowed in a Window, and the size of a Bucket.
+	Limit int64
+	// Window is the period Limit applies to. A Bucket refills Limit tokens in a Window.
+	Window time.Duration
+	// Clock provides the time. Use the cache's clock, so counters expire with the limiter's
+	// idea of time. @default the time package.
+	Clock cache.Clock
+}
+
+// Window is a sliding window rate limiter. Each key has a counter for the current window,
+// and one for the previous window; the previous count is weighted by how much of it the
+// sliding window still covers. This is an estimate, but needs only two counters per key.
+type Window struct {
+	cache  *cache.Cache
+	config Config
+	now    func() time.Time
+}
+
+// Bucket is a token bucket rate limiter. Each key has a bucket of Limit tokens, and every
+// request takes one. Tokens are added back at Limit per Window, so a key that was idle
+// may burst up to Limit requests at once.
+type Bucket struct {
+	cache  *cache.Cache
+	config Config
+	now    func() time.Time
+}
+
+// bucket is a key's token bucket, as stored in the cache.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewWindow returns a sliding window rate limiter, or an error wrapping
+// cache.ErrInvalidConfig if Limit or Window is not positive.
+func NewWindow(cache *cache.Cache, config Config) (*Window, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Window{cache: cache, config: config, now: now(config.Clock)}, nil
+}
+
+// NewBucket returns a token bucket rate limiter, or an error wrapping
+// cache.ErrInvalidConfig if Limit or Window is not positive.
+func NewBucket(cache *cache.Cache, config Config) (*Bucket, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Bucket{cache: cache, config: config, now: now(config.Clock)}, nil
+}
+
+// validate returns an error if the limit can not be enforced.
+func (c *Config) validate() error {
+	if c.Limit <= 0 || c.Window <= 0 {
+		return fmt.Errorf("%w: rate Limit and Window must be positive", cache.ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// now returns the Now function of a clock, or time.Now if it's nil.
+func now(clock cache.Clock) func() time.Time {
+	if clock == nil {
+		return time.Now
+	}
+
+	return clock.Now
+}
+
+// Allow returns true if a request for key is allowed, and counts it.
+func (w *Window) Allow(key string) bool {
+	return w.AllowN(key, 1)
+}
+
+// AllowN returns true if n requests for key are allowed, and counts them.
+// Requests that are not allowed are not counted.
+func (w *Window) AllowN(key string, n int64) bool {
+	now := w.now()
+	window := now.UnixNano() / int64(w.config.Window)
+	current := key + ":" + strconv.FormatInt(window, 10)
+
+	count, err := w.cache.Increment(current, n)
+	if err != nil {
+		return false
+	}
+
+	if count == n { // new counter: keep it through the next window, where it's the previous one.
+		end := time.Unix(0, (window+2)*int64(w.config.Window)) //nolint:mnd // this and the next window.
+		w.cache.Expire(current, end)
+	}
+
+	var previous int64
+	if data, ok := w.cache.GetData(key + ":" + strconv.FormatInt(window-1, 10)); ok {
+		previous, _ = data.(int64)
+	}
+
+	elapsed := now.UnixNano() % int64(w.config.Window)
+	weight := float64(int64(w.config.Window)-elapsed) / float64(w.config.Window)
+
+	if float64(previous)*weight+float64(count) <= float64(w.config.Limit) {
+		return true
+	}
+
+	_, _ = w.cache.Increment(current, -n)
+
+	return false
+}
+
+// Allow returns true if a request for key is allowed, and takes a token for it.
+func (b *Bucket) Allow(key string) bool {
+	return b.AllowN(key, 1)
+}
+
+// AllowN returns true if n requests for key are allowed, and takes n tokens for them.
+// No tokens are taken if the requests are not allowed.
+func (b *Bucket) AllowN(key string, n int64) bool {
+	now := b.now()
+	rate := float64(b.config.Limit) / float64(b.config.Window)
+	allowed := false
+
+	b.cache.Compute(key, func(old *cache.Item) (any, cache.Options, bool) {
+		state := bucket{tokens: float64(b.config.Limit), last: now}
+		if old != nil {
+			if saved, ok := old.Data.(bucket); ok {
+				state.tokens = min(state.tokens, saved.tokens+float64(now.Sub(saved.last))*rate)
+			}
+		}
+
+		if allowed = state.tokens >= float64(n); allowed {
+			state.tokens -= float64(n)
+		}
+
+		// The bucket is full again after this long; a missing bucket is a full bucket.
+		refill := time.Duration((float64(b.config.Limit) - state.tokens) / rate)
+
+		return state, cache.Options{Expire: now.Add(refill)}, refill > 0
+	})
+
+	return allowed
+}
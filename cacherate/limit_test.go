@@ -0,0 +1,89 @@
+package cacherate_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"golift.io/cache"
+	"golift.io/cache/cacherate"
+	"golift.io/cache/cachetest"
+)
+
+func ExampleNewBucket() {
+	clock := cachetest.NewClock()
+	limits := cache.New(cache.Config{Clock: clock})
+	defer limits.Stop(true)
+
+	// Two requests per minute, with bursts of two.
+	limiter, err := cacherate.NewBucket(limits.Namespace("api"), cacherate.Config{Limit: 2, Window: time.Minute, Clock: clock})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(limiter.Allow("192.0.2.1"), limiter.Allow("192.0.2.1"), limiter.Allow("192.0.2.1"))
+	fmt.Println("Other IP:", limiter.Allow("192.0.2.2"))
+
+	clock.Advance(30 * time.Second) // one token comes back.
+	fmt.Println(limiter.Allow("192.0.2.1"), limiter.Allow("192.0.2.1"))
+	// Output:
+	// true true false
+	// Other IP: true
+	// true false
+}
+
+func TestWindow(t *testing.T) {
+	t.Parallel()
+
+	clock := cachetest.NewClock()
+	limits := cache.New(cache.Config{Clock: clock})
+	t.Cleanup(func() { limits.Stop(true) })
+
+	limiter, err := cacherate.NewWindow(limits, cacherate.Config{Limit: 4, Window: time.Minute, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allow := func(expect bool, when string) {
+		t.Helper()
+
+		if limiter.Allow("user") != expect {
+			t.Fatalf("expected %v %s", expect, when)
+		}
+	}
+
+	for idx := 0; idx < 4; idx++ {
+		allow(true, "under the limit")
+	}
+
+	allow(false, "over the limit")
+	clock.Advance(30 * time.Second)
+	allow(false, "later in the same window")
+
+	// 15 seconds into the next window, 3/4 of the previous window's 4 requests still count.
+	clock.Advance(45 * time.Second)
+	allow(true, "with room for one request")
+	allow(false, "after the room was used")
+
+	if !limiter.AllowN("other", 4) || limiter.AllowN("other", 1) {
+		t.Fatal("AllowN counted the wrong number of requests")
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	limits := cache.New(cache.Config{})
+	t.Cleanup(func() { limits.Stop(true) })
+
+	for _, config := range []cacherate.Config{{Limit: 1}, {Window: time.Second}, {Limit: -1, Window: time.Second}} {
+		if _, err := cacherate.NewWindow(limits, config); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("NewWindow accepted %+v: %v", config, err)
+		}
+
+		if _, err := cacherate.NewBucket(limits, config); !errors.Is(err, cache.ErrInvalidConfig) {
+			t.Errorf("NewBucket accepted %+v: %v", config, err)
+		}
+	}
+}
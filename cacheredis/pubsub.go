@@ -0,0 +1,58 @@
+package cacheredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSubscriptionClosed is returned by PubSub.Subscribe if Redis closes the subscription.
+var ErrSubscriptionClosed = errors.New("redis subscription closed")
+
+// PubSub is a cacheinvalidate.Transport that sends messages with Redis pub/sub on a Channel.
+// Every replica must use the same Channel.
+type PubSub struct {
+	Client  redis.UniversalClient
+	Channel string
+}
+
+// Publish sends a message to every replica subscribed to the Channel.
+func (p *PubSub) Publish(ctx context.Context, message []byte) error {
+	if err := p.Client.Publish(ctx, p.Channel, message).Err(); err != nil {
+		return fmt.Errorf("publishing to redis: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe calls handle with every message sent to the Channel until ctx is done.
+// It returns after the subscription fails, or after ctx is done.
+func (p *PubSub) Subscribe(ctx context.Context, handle func(message []byte)) error {
+	sub := p.Client.Subscribe(ctx, p.Channel)
+	defer sub.Close()
+
+	if _, err := sub.Receive(ctx); err != nil { // wait for the subscription.
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return fmt.Errorf("subscribing to redis: %w", err)
+	}
+
+	messages := sub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return ErrSubscriptionClosed
+			}
+
+			handle([]byte(msg.Payload))
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package cacheredis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"golift.io/cache"
+	"golift.io/cache/cacheinvalidate"
+	"golift.io/cache/cacheredis"
+)
+
+func TestPubSub(t *testing.T) {
+	t.Parallel()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t.Cleanup(func() {
+		cancel()
+		client.Close()
+	})
+
+	first, second := cache.New(cache.Config{}), cache.New(cache.Config{})
+	t.Cleanup(func() { first.Stop(true); second.Stop(true) })
+
+	transport := &cacheredis.PubSub{Client: client, Channel: "invalidations"}
+	firstInv := cacheinvalidate.New(first, transport)
+	secondInv := cacheinvalidate.New(second, transport)
+
+	go firstInv.Run(ctx)  //nolint:errcheck // the test fails if it does not run.
+	go secondInv.Run(ctx) //nolint:errcheck
+
+	waitFor(t, func() bool { return server.PubSubNumSub("invalidations")["invalidations"] == 2 })
+
+	first.Save("admin", "Super Dooper", cache.Options{})
+	second.Save("admin", "Super Dooper", cache.Options{})
+
+	if err := secondInv.Delete(ctx, "admin"); err != nil {
+		t.Fatalf("deleting: %v", err)
+	}
+
+	waitFor(t, func() bool { return first.Get("admin") == nil })
+}
+
+// waitFor fails the test if ready does not return true within a few seconds.
+func waitFor(t *testing.T, ready func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); !ready(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Package cacheredis provides a two-tier cache: a golift.io/cache in memory in front of Redis.
+// Reads are served from memory when possible, and misses are looked up in Redis, which
+// every instance of an app shares. Writes go to both tiers.
+//
+// This package is its own Go module, so the cache module does not depend on a Redis client.
+package cacheredis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golift.io/cache"
+)
+
+// Config is the optional configuration for a Tiered cache.
+type Config struct {
+	// Prefix is added to every Redis key, like "users:". Use it to share a Redis
+	// database between caches.
+	Prefix string
+	// Codec encodes items in Redis. Every instance must use the same codec.
+	// @default cache.GobCodec, which requires gob.Register() for every type of data saved.
+	Codec cache.Codec
+	// LocalTTL is the longest an item from Redis is kept in memory. Another instance may
+	// change or delete the item in Redis, and this instance keeps the old item until
+	// it expires here, so keep this short if that matters.
+	// @default 0, until the item expires or is pruned.
+	LocalTTL time.Duration
+}
+
+// Tiered is a cache with two tiers: L1 in memory, and Redis. Create one with New.
+// It's safe for concurrent use.
+type Tiered struct {
+	// L1 is the in-memory cache. Use it for stats, or to read only local items.
+	L1     *cache.Cache
+	client redis.UniversalClient
+	config Config
+}
+
+// New returns a Tiered cache that keeps items in l1 and in Redis with client.
+// Start l1 before using the Tiered cache, and stop it when it's no longer needed.
+func New(l1 *cache.Cache, client redis.UniversalClient, config Config) *Tiered {
+	if config.Codec == nil {
+		config.Codec = cache.GobCodec{}
+	}
+
+	return &Tiered{L1: l1, client: client, config: config}
+}
+
+// Get returns an item from memory, or from Redis if it's not in memory. Items found in
+// Redis are saved in memory for the next Get. This returns nil, and no error, if the key
+// is not in either tier. Errors are only returned by Redis and the Codec.
+func (t *Tiered) Get(ctx context.Context, key string) (*cache.Item, error) {
+	if item := t.L1.Get(key); item != nil {
+		return item, nil
+	}
+
+	data, err := t.client.Get(ctx, t.config.Prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil //nolint:nilnil // a miss is not an error.
+	} else if err != nil {
+		return nil, fmt.Errorf("getting %s from redis: %w", key, err)
+	}
+
+	var entry cache.Entry
+	if err := t.config.Codec.Decode(bytes.NewReader(data), &entry); err != nil {
+		return nil, fmt.Errorf("decoding %s from redis: %w", key, err)
+	}
+
+	opts := entry.Options
+	if t.config.LocalTTL > 0 {
+		if expire := time.Now().Add(t.config.LocalTTL); opts.Expire.IsZero() || expire.Before(opts.Expire) {
+			opts.Expire = expire
+		}
+	}
+
+	t.L1.Save(key, entry.Data, opts)
+
+	return &cache.Item{
+		Data:     entry.Data,
+		Time:     entry.Time,
+		Last:     time.Now(),
+		Hits:     1,
+		Expires:  entry.Options.Expire,
+		Prunable: entry.Options.Prune || entry.Options.PruneAfter > 0,
+		Pinned:   entry.Options.Pin,
+		Meta:     entry.Options.Meta,
+		Version:  entry.Version,
+	}, nil
+}
+
+// Save saves an item in Redis, and then in memory. The Redis key expires with the item's
+// Expire or TTL option. Nothing is saved in memory if Redis returns an error.
+func (t *Tiered) Save(ctx context.Context, key string, data any, opts cache.Options) error {
+	now := time.Now()
+	if opts.TTL > 0 && opts.Expire.IsZero() {
+		opts.Expire = now.Add(opts.TTL)
+	}
+
+	var ttl time.Duration
+	if !opts.Expire.IsZero() {
+		if ttl = opts.Expire.Sub(now); ttl <= 0 {
+			return t.Delete(ctx, key) // already expired.
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := t.config.Codec.Encode(&buf, &cache.Entry{Key: key, Data: data, Time: now, Last: now, Options: opts}); err != nil {
+		return fmt.Errorf("encoding %s for redis: %w", key, err)
+	}
+
+	if err := t.client.Set(ctx, t.config.Prefix+key, buf.Bytes(), ttl).Err(); err != nil {
+		return fmt.Errorf("saving %s in redis: %w", key, err)
+	}
+
+	t.L1.Save(key, data, opts)
+
+	return nil
+}
+
+// Delete deletes an item from memory and from Redis.
+func (t *Tiered) Delete(ctx context.Context, key string) error {
+	t.L1.Delete(key)
+
+	if err := t.client.Del(ctx, t.config.Prefix+key).Err(); err != nil {
+		return fmt.Errorf("deleting %s from redis: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package cacheredis_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"golift.io/cache"
+	"golift.io/cache/cacheredis"
+)
+
+func ExampleNew() {
+	server := miniredis.NewMiniRedis() // use a real Redis server.
+	if err := server.Start(); err != nil {
+		panic(err)
+	}
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+
+	// Two instances of an app, each with its own memory, share Redis.
+	first := cacheredis.New(cache.New(cache.Config{}), client, cacheredis.Config{Prefix: "users:"})
+	defer first.L1.Stop(true)
+
+	second := cacheredis.New(cache.New(cache.Config{}), client, cacheredis.Config{Prefix: "users:"})
+	defer second.L1.Stop(true)
+
+	if err := first.Save(ctx, "admin", "Super Dooper", cache.Options{}); err != nil {
+		panic(err)
+	}
+
+	fmt.Println("in memory:", second.L1.Get("admin"))
+
+	item, err := second.Get(ctx, "admin")
+	fmt.Println("from redis:", item.Data, err)
+	fmt.Println("in memory:", second.L1.Get("admin").Data)
+	fmt.Println("keys in redis:", server.Keys())
+	// Output:
+	// in memory: <nil>
+	// from redis: Super Dooper <nil>
+	// in memory: Super Dooper
+	// keys in redis: [users:admin]
+}
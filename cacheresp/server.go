@@ -0,0 +1,419 @@
+// Package cacheresp provides a minimal server that speaks the Redis serialization
+// protocol (RESP), backed by a golift.io/cache Cache. Point redis-cli, or a Redis client
+// library, at an embedded cache for debugging and lightweight deployments.
+//
+// Supported commands: PING, GET, SET (with EX, PX, NX and XX), DEL, EXPIRE, TTL, KEYS,
+// COMMAND and QUIT. Values are stored as []byte. Cached strings, []byte and integers
+// saved by Go code are served too. Expired keys are not returned by GET; the cache pruner
+// removes them from KEYS.
+package cacheresp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golift.io/cache"
+)
+
+// maxBulk is the largest bulk string a client may send; the Redis default is 512MB.
+const maxBulk = 512 << 20
+
+// Declared lengths are not trusted for allocations: memory for more arguments,
+// or more bulk string bytes, than these is only allocated as the data arrives.
+const (
+	preallocArgs = 64
+	preallocBulk = 64 << 10
+)
+
+// Server is a RESP server.
+type Server struct {
+	cache *cache.Cache
+}
+
+// errProtocol is returned when a client sends data that is not valid RESP.
+var errProtocol = errors.New("Protocol error") //nolint:stylecheck // Redis error format.
+
+// New returns a RESP server that stores items in the provided cache.
+// Use a cache Namespace if the cache is used for other data too.
+func New(cache *cache.Cache) *Server {
+	return &Server{cache: cache}
+}
+
+// ListenAndServe listens on the TCP network address addr and serves Redis clients.
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening: %w", err)
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on the listener and serves each in a go routine.
+// This returns when the listener is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return fmt.Errorf("accepting connection: %w", err)
+		}
+
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serves a single client connection until it sends QUIT or disconnects.
+// The connection is closed when this returns.
+func (s *Server) ServeConn(conn io.ReadWriteCloser) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+
+	for {
+		args, err := readCommand(reader)
+		if errors.Is(err, errProtocol) {
+			writeError(writer, err.Error())
+			writer.Flush()
+
+			return
+		} else if err != nil {
+			return
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := strings.EqualFold(args[0], "quit")
+		if quit {
+			writeStatus(writer, "OK")
+		} else {
+			s.command(writer, strings.ToUpper(args[0]), args[1:])
+		}
+
+		if err := writer.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// command runs one command and writes the reply.
+func (s *Server) command(writer *bufio.Writer, cmd string, args []string) {
+	switch {
+	case cmd == "PING" && len(args) == 0:
+		writeStatus(writer, "PONG")
+	case cmd == "PING" && len(args) == 1:
+		writeBulk(writer, []byte(args[0]))
+	case cmd == "COMMAND":
+		writer.WriteString("*0\r\n") // redis-cli asks for command docs when it connects.
+	case cmd == "GET" && len(args) == 1:
+		s.get(writer, args[0])
+	case cmd == "SET" && len(args) >= 2: //nolint:mnd // key and value.
+		s.set(writer, args)
+	case cmd == "DEL" && len(args) >= 1:
+		deleted := 0
+
+		for _, key := range args {
+			if s.cache.Delete(key) {
+				deleted++
+			}
+		}
+
+		writeInt(writer, int64(deleted))
+	case cmd == "EXPIRE" && len(args) == 2: //nolint:mnd // key and seconds.
+		s.expire(writer, args[0], args[1])
+	case cmd == "TTL" && len(args) == 1:
+		s.ttl(writer, args[0])
+	case cmd == "KEYS" && len(args) == 1:
+		s.keys(writer, args[0])
+	case cmd == "PING", cmd == "GET", cmd == "SET", cmd == "DEL",
+		cmd == "EXPIRE", cmd == "TTL", cmd == "KEYS":
+		writeError(writer, "ERR wrong number of arguments for '"+strings.ToLower(cmd)+"' command")
+	default:
+		writeError(writer, "ERR unknown command '"+cmd+"'")
+	}
+}
+
+func (s *Server) get(writer *bufio.Writer, key string) {
+	item := s.cache.Get(key)
+	if item == nil {
+		writer.WriteString("$-1\r\n")
+		return
+	}
+
+	switch data := item.Data.(type) {
+	case []byte:
+		writeBulk(writer, data)
+	case string:
+		writeBulk(writer, []byte(data))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		writeBulk(writer, []byte(fmt.Sprint(data)))
+	default:
+		writeError(writer, "WRONGTYPE Operation against a key holding the wrong kind of value")
+	}
+}
+
+// set runs: SET key value [NX | XX] [EX seconds | PX milliseconds].
+func (s *Server) set(writer *bufio.Writer, args []string) {
+	var (
+		key, value = args[0], []byte(args[1])
+		opts       cache.Options
+		nx, xx     bool
+	)
+
+	for idx := 2; idx < len(args); idx++ {
+		switch option := strings.ToUpper(args[idx]); {
+		case option == "NX":
+			nx = true
+		case option == "XX":
+			xx = true
+		case (option == "EX" || option == "PX") && idx+1 < len(args):
+			idx++
+
+			amount, err := strconv.ParseInt(args[idx], 10, 64)
+			if err != nil || amount <= 0 {
+				writeError(writer, "ERR invalid expire time in 'set' command")
+				return
+			}
+
+			unit := time.Second
+			if option == "PX" {
+				unit = time.Millisecond
+			}
+
+			opts.Expire = time.Now().Add(time.Duration(amount) * unit)
+		default:
+			writeError(writer, "ERR syntax error")
+			return
+		}
+	}
+
+	switch {
+	case nx && xx:
+		writeError(writer, "ERR syntax error")
+	case nx && !s.cache.CompareAndSwap(key, nil, value, opts):
+		writer.WriteString("$-1\r\n")
+	case xx && !s.cache.Replace(key, value, opts):
+		writer.WriteString("$-1\r\n")
+	default:
+		if !nx && !xx {
+			s.cache.Save(key, value, opts)
+		}
+
+		writeStatus(writer, "OK")
+	}
+}
+
+func (s *Server) expire(writer *bufio.Writer, key, seconds string) {
+	amount, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		writeError(writer, "ERR value is not an integer or out of range")
+		return
+	}
+
+	if amount <= 0 {
+		// Redis deletes keys with a non-positive expire time.
+		writeBool(writer, s.cache.Delete(key))
+		return
+	}
+
+	writeBool(writer, s.cache.Expire(key, time.Now().Add(time.Duration(amount)*time.Second)))
+}
+
+func (s *Server) ttl(writer *bufio.Writer, key string) {
+	switch ttl, ok := s.cache.TTL(key); {
+	case !ok:
+		writeInt(writer, -2) //nolint:mnd // the key does not exist.
+	case ttl == cache.Forever:
+		writeInt(writer, -1) // the key does not expire.
+	case ttl < 0:
+		writeInt(writer, 0) // expired, waiting for the pruner.
+	default:
+		writeInt(writer, int64((ttl+time.Second-1)/time.Second))
+	}
+}
+
+func (s *Server) keys(writer *bufio.Writer, pattern string) {
+	matches := []string{}
+
+	for _, key := range s.cache.Keys() {
+		if match(pattern, key) {
+			matches = append(matches, key)
+		}
+	}
+
+	fmt.Fprintf(writer, "*%d\r\n", len(matches))
+
+	for _, key := range matches {
+		writeBulk(writer, []byte(key))
+	}
+}
+
+// match reports whether a key matches a Redis glob pattern, like KEYS does:
+// * matches any bytes, including a slash, ? matches one byte, [abc] and [a-z] match
+// a byte in a set, [^abc] matches a byte not in it, and \ escapes the next byte.
+// Like Redis, a set without a closing ] ends at the end of the pattern.
+func match(pattern, key string) bool {
+	star, starKey := -1, 0 // where the last * was, and the key byte it matches up to.
+	pat, idx := 0, 0
+
+	for idx < len(key) {
+		if pat < len(pattern) && pattern[pat] == '*' {
+			star, starKey = pat, idx
+			pat++
+
+			continue
+		}
+
+		if pat < len(pattern) {
+			if ok, next := matchByte(pattern, pat, key[idx]); ok {
+				pat, idx = next, idx+1
+				continue
+			}
+		}
+
+		if star < 0 {
+			return false
+		}
+
+		// Let the last * match one more byte, and try the rest of the pattern again.
+		starKey++
+		pat, idx = star+1, starKey
+	}
+
+	for pat < len(pattern) && pattern[pat] == '*' {
+		pat++
+	}
+
+	return pat == len(pattern)
+}
+
+// matchByte matches one byte with the pattern element at pat, which is not a *,
+// and returns the position of the next element.
+func matchByte(pattern string, pat int, char byte) (bool, int) {
+	switch pattern[pat] {
+	case '?':
+		return true, pat + 1
+	case '[':
+		return matchSet(pattern, pat+1, char)
+	case '\\':
+		if pat+1 < len(pattern) {
+			pat++
+		}
+	}
+
+	return pattern[pat] == char, pat + 1
+}
+
+// matchSet matches one byte with the set that starts at pat, after the [,
+// and returns the position after the closing ].
+func matchSet(pattern string, pat int, char byte) (bool, int) {
+	negate := pat < len(pattern) && pattern[pat] == '^'
+	if negate {
+		pat++
+	}
+
+	found := false
+
+	for pat < len(pattern) && pattern[pat] != ']' {
+		switch {
+		case pattern[pat] == '\\' && pat+1 < len(pattern):
+			found = found || pattern[pat+1] == char
+			pat += 2
+		case pat+2 < len(pattern) && pattern[pat+1] == '-' && pattern[pat+2] != ']':
+			low, high := min(pattern[pat], pattern[pat+2]), max(pattern[pat], pattern[pat+2])
+			found = found || (low <= char && char <= high)
+			pat += 3
+		default:
+			found = found || pattern[pat] == char
+			pat++
+		}
+	}
+
+	if pat < len(pattern) {
+		pat++ // the closing ].
+	}
+
+	return found != negate, pat
+}
+
+// readCommand reads an array of bulk strings, or an inline command, from a client.
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := readLine(reader)
+	if err != nil || !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), err // inline command, like from telnet.
+	}
+
+	count, err := strconv.Atoi(line[1:])
+	if err != nil || count > 1024*1024 {
+		return nil, fmt.Errorf("%w: invalid multibulk length", errProtocol)
+	}
+
+	args := make([]string, 0, min(max(count, 0), preallocArgs))
+
+	for ; count > 0; count-- {
+		if line, err = readLine(reader); err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimPrefix(line, "$"))
+		if !strings.HasPrefix(line, "$") || err != nil || size < 0 || size > maxBulk {
+			return nil, fmt.Errorf("%w: invalid bulk length", errProtocol)
+		}
+
+		var data bytes.Buffer
+
+		data.Grow(min(size+2, preallocBulk)) //nolint:mnd // \r\n
+		if _, err = io.CopyN(&data, reader, int64(size+2)); err != nil {
+			return nil, err //nolint:wrapcheck // the connection is closed on error.
+		}
+
+		args = append(args, string(data.Bytes()[:size]))
+	}
+
+	return args, nil
+}
+
+// readLine reads a line and removes the \r\n from it.
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err //nolint:wrapcheck // the connection is closed on error.
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func writeStatus(writer *bufio.Writer, status string) {
+	writer.WriteString("+" + status + "\r\n")
+}
+
+func writeError(writer *bufio.Writer, msg string) {
+	writer.WriteString("-" + msg + "\r\n")
+}
+
+func writeInt(writer *bufio.Writer, value int64) {
+	writer.WriteString(":" + strconv.FormatInt(value, 10) + "\r\n")
+}
+
+func writeBool(writer *bufio.Writer, value bool) {
+	if value {
+		writeInt(writer, 1)
+	} else {
+		writeInt(writer, 0)
+	}
+}
+
+func writeBulk(writer *bufio.Writer, data []byte) {
+	writer.WriteString("$" + strconv.Itoa(len(data)) + "\r\n")
+	writer.Write(data)
+	writer.WriteString("\r\n")
+}
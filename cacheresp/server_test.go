@@ -0,0 +1,120 @@
+package cacheresp_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golift.io/cache"
+	"golift.io/cache/cacheresp"
+)
+
+func ExampleServer_ServeConn() {
+	shared := cache.New(cache.Config{})
+	defer shared.Stop(true)
+
+	client, conn := net.Pipe()
+	defer client.Close()
+
+	go cacheresp.New(shared).ServeConn(conn)
+
+	reader := bufio.NewReader(client)
+	// Send a command as an array of bulk strings and print the reply.
+	send := func(args ...string) {
+		fmt.Fprintf(client, "*%d\r\n", len(args))
+
+		for _, arg := range args {
+			fmt.Fprintf(client, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+
+		fmt.Println(readReply(reader))
+	}
+
+	shared.Save("from-go", "hello", cache.Options{})
+	send("SET", "user:1", "alice", "EX", "60")
+	send("SET", "user:1", "bob", "NX")
+	send("GET", "user:1")
+	send("TTL", "user:1")
+	send("TTL", "from-go")
+	send("KEYS", "user:*")
+	send("GET", "from-go")
+	send("DEL", "user:1", "missing")
+	send("GET", "user:1")
+	// Output:
+	// OK
+	// (nil)
+	// "alice"
+	// 60
+	// -1
+	// ["user:1"]
+	// "hello"
+	// 1
+	// (nil)
+}
+
+func TestKeys(t *testing.T) {
+	t.Parallel()
+
+	shared := cache.New(cache.Config{})
+	t.Cleanup(func() { shared.Stop(true) })
+
+	for _, key := range []string{"a/b", "user:1", "user:22", "hat", "hit", "h*t", "[x]"} {
+		shared.Save(key, "v", cache.Options{})
+	}
+
+	client, conn := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+
+	go cacheresp.New(shared).ServeConn(conn)
+
+	reader := bufio.NewReader(client)
+
+	for pattern, expect := range map[string]string{
+		"*":        `["[x]", "a/b", "h*t", "hat", "hit", "user:1", "user:22"]`,
+		"a*":       `["a/b"]`,
+		"*/*":      `["a/b"]`,
+		"user:?":   `["user:1"]`,
+		"user:*2":  `["user:22"]`,
+		"h[ai]t":   `["hat", "hit"]`,
+		"h[^a]t":   `["h*t", "hit"]`,
+		"h[a-c]t":  `["hat"]`,
+		`h\*t`:     `["h*t"]`,
+		`\[x\]`:    `["[x]"]`,
+		"h[ai":     `[]`,
+		"**user:1": `["user:1"]`,
+	} {
+		fmt.Fprintf(client, "KEYS %s\r\n", pattern)
+
+		if got := readReply(reader); got != expect {
+			t.Errorf("KEYS %s returned %s, expected %s", pattern, got, expect)
+		}
+	}
+}
+
+// readReply reads a reply and formats it a bit like redis-cli does.
+func readReply(reader *bufio.Reader) string {
+	line, _ := reader.ReadString('\n')
+	line = strings.TrimSpace(line)
+
+	switch {
+	case line == "$-1":
+		return "(nil)"
+	case strings.HasPrefix(line, "$"):
+		data, _ := reader.ReadString('\n')
+		return strconv.Quote(strings.TrimSpace(data))
+	case strings.HasPrefix(line, "*"):
+		count, _ := strconv.Atoi(line[1:])
+		items := make([]string, count)
+
+		for idx := range items {
+			items[idx] = readReply(reader)
+		}
+
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return line[1:]
+	}
+}
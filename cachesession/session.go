@@ -0,0 +1,102 @@
+// Package cachesession stores web sessions in a golift.io/cache Cache, so an app can use
+// its cache as the session backend. Every session has its own TTL, and expired sessions
+// are removed by the cache pruner, or when they're loaded.
+//
+// Store has Load, Save and Destroy methods for session values, and Find, Commit, Delete
+// and All methods for encoded sessions, so it satisfies the Store and IterableStore
+// interfaces in github.com/alexedwards/scs/v2 without importing it:
+//
+//	sessionManager := scs.New()
+//	sessionManager.Store = cachesession.New(appCache.Namespace("sessions"), 24*time.Hour)
+//
+// Sessions are only kept in memory, so they're lost when the process restarts,
+// unless the cache has a Journal or snapshots.
+package cachesession
+
+import (
+	"maps"
+	"time"
+
+	"golift.io/cache"
+)
+
+// Store keeps sessions in a cache. Use a cache Namespace for sessions if the cache is
+// used for other data too; All returns every item in the cache that holds a session.
+type Store struct {
+	cache *cache.Cache
+	ttl   time.Duration
+}
+
+// New returns a session store. Sessions saved without a TTL expire after ttl.
+// A ttl of 0 keeps them until they're destroyed, or pruned by the cache.
+func New(cache *cache.Cache, ttl time.Duration) *Store {
+	return &Store{cache: cache, ttl: ttl}
+}
+
+// Load returns a copy of a session's values, and false if the session does not exist or expired.
+func (s *Store) Load(id string) (map[string]any, bool) {
+	values, ok := s.cache.GetData(id)
+	if !ok {
+		return nil, false
+	}
+
+	session, ok := values.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	return maps.Clone(session), true
+}
+
+// Save stores a copy of a session's values, and returns true if the session already existed.
+// The session expires after ttl, or after the store's TTL if ttl is 0.
+func (s *Store) Save(id string, values map[string]any, ttl time.Duration) bool {
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+
+	return s.cache.Save(id, maps.Clone(values), cache.Options{TTL: ttl})
+}
+
+// Destroy removes a session, and returns true if it existed.
+func (s *Store) Destroy(id string) bool {
+	return s.cache.Delete(id)
+}
+
+// Find returns an encoded session, and false if the session does not exist or expired.
+// The error is always nil.
+func (s *Store) Find(token string) ([]byte, bool, error) {
+	data, ok := s.cache.GetData(token)
+	if !ok {
+		return nil, false, nil
+	}
+
+	session, ok := data.([]byte)
+
+	return session, ok, nil
+}
+
+// Commit stores an encoded session that expires at expiry. The error is always nil.
+func (s *Store) Commit(token string, session []byte, expiry time.Time) error {
+	s.cache.Save(token, session, cache.Options{Expire: expiry})
+	return nil
+}
+
+// Delete removes an encoded session. The error is always nil.
+func (s *Store) Delete(token string) error {
+	s.cache.Delete(token)
+	return nil
+}
+
+// All returns every encoded session that has not expired, by token. The error is always nil.
+func (s *Store) All() (map[string][]byte, error) {
+	sessions := make(map[string][]byte)
+
+	for token, item := range s.cache.List() {
+		if session, ok := item.Data.([]byte); ok && !item.Expired() {
+			sessions[token] = session
+		}
+	}
+
+	return sessions, nil
+}
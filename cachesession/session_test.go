@@ -0,0 +1,53 @@
+package cachesession_test
+
+import (
+	"fmt"
+	"time"
+
+	"golift.io/cache"
+	"golift.io/cache/cachesession"
+	"golift.io/cache/cachetest"
+)
+
+func ExampleNew() {
+	clock := cachetest.NewClock()
+	appCache := cache.New(cache.Config{Clock: clock})
+	defer appCache.Stop(true)
+
+	sessions := cachesession.New(appCache.Namespace("sessions"), time.Hour)
+	sessions.Save("abc", map[string]any{"user": "admin"}, 0)
+	sessions.Save("def", map[string]any{"user": "guest"}, time.Minute)
+
+	values, ok := sessions.Load("abc")
+	fmt.Println(values["user"], ok)
+
+	clock.Advance(2 * time.Minute)
+
+	_, ok = sessions.Load("def")
+	fmt.Println("Guest expired:", !ok)
+	fmt.Println("Destroyed:", sessions.Destroy("abc"))
+	// Output:
+	// admin true
+	// Guest expired: true
+	// Destroyed: true
+}
+
+func ExampleStore_Commit() {
+	sessionCache := cache.New(cache.Config{})
+	defer sessionCache.Stop(true)
+
+	sessions := cachesession.New(sessionCache, 0)
+
+	_ = sessions.Commit("abc", []byte(`{"user":"admin"}`), time.Now().Add(time.Hour))
+	_ = sessions.Commit("def", []byte(`{"user":"guest"}`), time.Now().Add(time.Hour))
+	_ = sessions.Delete("def")
+
+	session, found, _ := sessions.Find("abc")
+	fmt.Println(string(session), found)
+
+	all, _ := sessions.All()
+	fmt.Println(len(all), "session")
+	// Output:
+	// {"user":"admin"} true
+	// 1 session
+}
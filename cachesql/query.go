@@ -0,0 +1,241 @@
+// Package cachesql caches database/sql query results in a golift.io/cache Cache.
+// Results are keyed by the normalized query and its arguments, and tagged with the tables
+// the query reads. Writing to a table through Exec, or calling Invalidate with its name,
+// invalidates every cached result that read it.
+//
+// Table names are found with a simple scan for the name after each FROM, JOIN, INTO and
+// UPDATE, so the scan misses tables listed after a comma, and the tables behind views and
+// functions. Results that read those are only invalidated by their TTL, or by calling
+// Invalidate with a name the scan found. Invalidation is
+// local to the process; use a TTL that bounds staleness from writes made by other processes.
+package cachesql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golift.io/cache"
+)
+
+// Result is a cached query result. It's shared by every caller that gets it from the cache,
+// so do not change it. Values are what the driver returned, like int64, float64, bool,
+// []byte, string, time.Time or nil.
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// DB runs queries on a database and caches the results.
+type DB struct {
+	db    *sql.DB
+	cache *cache.Cache
+	ttl   time.Duration
+	mu    sync.Mutex
+	gens  map[string]uint64 // table invalidation counters, by table name.
+}
+
+// cached is a result saved in the cache, with the invalidation counters of its tables
+// from when the query started.
+type cached struct {
+	result *Result
+	tables []string
+	gens   []uint64
+}
+
+// tablePattern finds the table names in a query.
+var tablePattern = regexp.MustCompile("(?i)\\b(?:from|join|into|update)\\s+([\\w.`\"\\[\\]]+)")
+
+// New returns a DB that caches query results in a cache for ttl. A ttl of 0 caches results
+// until they're invalidated or pruned. Use a cache Namespace if the cache has other data.
+func New(db *sql.DB, cache *cache.Cache, ttl time.Duration) *DB {
+	return &DB{db: db, cache: cache, ttl: ttl, gens: make(map[string]uint64)}
+}
+
+// Query returns the cached result for a query and its arguments, or runs the query and
+// caches the result. The query is run as it's written; it's only normalized for the key.
+// Errors are not cached. A result that was invalidated while its query was running is
+// returned to the caller, but the next call runs the query again.
+func (d *DB) Query(ctx context.Context, query string, args ...any) (*Result, error) {
+	normal := Normalize(query)
+	key := queryKey(normal, args)
+	tables := Tables(normal)
+	gens := d.generations(tables)
+
+	if item := d.cache.Get(key); item != nil {
+		if saved, ok := item.Data.(*cached); ok && d.current(saved) {
+			return saved.result, nil
+		}
+	}
+
+	result, err := d.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	d.cache.Save(key, &cached{result: result, tables: tables, gens: gens}, cache.Options{TTL: d.ttl})
+
+	return result, nil
+}
+
+// query runs a query and reads every row.
+func (d *DB) query(ctx context.Context, query string, args []any) (*Result, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+	defer rows.Close()
+
+	result := &Result{}
+	if result.Columns, err = rows.Columns(); err != nil {
+		return nil, fmt.Errorf("reading columns: %w", err)
+	}
+
+	for rows.Next() {
+		row := make([]any, len(result.Columns))
+		dest := make([]any, len(row))
+
+		for idx := range row {
+			dest[idx] = &row[idx]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("reading row: %w", err)
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// Exec runs a statement, like an INSERT, UPDATE or DELETE, and invalidates the cached
+// results for the tables it writes to. The tables are invalidated even if it fails,
+// because it may have changed them before it failed.
+func (d *DB) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	defer d.Invalidate(Tables(query)...)
+
+	result, err := d.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("executing: %w", err)
+	}
+
+	return result, nil
+}
+
+// Invalidate invalidates every cached result that read any of these tables.
+// Table names are not case sensitive, and may include quotes and a schema, like "main".users.
+func (d *DB) Invalidate(tables ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, table := range tables {
+		d.gens[tableName(table)]++
+	}
+}
+
+// generations returns the invalidation counters for tables.
+func (d *DB) generations(tables []string) []uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	gens := make([]uint64, len(tables))
+	for idx, table := range tables {
+		gens[idx] = d.gens[table]
+	}
+
+	return gens
+}
+
+// current returns true if none of a cached result's tables were invalidated since its query started.
+func (d *DB) current(saved *cached) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for idx, table := range saved.tables {
+		if d.gens[table] != saved.gens[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Normalize collapses every run of white space outside of quotes in a query to one space,
+// and trims white space and semicolons from the ends, so formatting does not change the cache key.
+func Normalize(query string) string {
+	var (
+		out   strings.Builder
+		quote rune
+		space bool
+	)
+
+	out.Grow(len(query))
+
+	for _, char := range strings.Trim(query, " \t\r\n;") {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == ' ' || char == '\t' || char == '\r' || char == '\n':
+			space = true
+			continue
+		}
+
+		if space {
+			out.WriteByte(' ')
+			space = false
+		}
+
+		out.WriteRune(char)
+	}
+
+	return out.String()
+}
+
+// Tables returns the lowercase names of the tables a query reads or writes, without
+// quotes, in the order they appear. Names are not repeated.
+func Tables(query string) []string {
+	var tables []string
+
+	for _, match := range tablePattern.FindAllStringSubmatch(query, -1) {
+		table := tableName(match[1])
+
+		if !slices.Contains(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+
+	return tables
+}
+
+// tableName lowercases a table name and removes its quotes.
+func tableName(table string) string {
+	return strings.ToLower(strings.NewReplacer("`", "", `"`, "", "[", "", "]", "").Replace(table))
+}
+
+// queryKey returns the cache key for a normalized query and its arguments.
+func queryKey(query string, args []any) string {
+	hash := sha256.New()
+	hash.Write([]byte(query))
+
+	for _, arg := range args {
+		fmt.Fprintf(hash, "\x00%T:%v", arg, arg)
+	}
+
+	return "sql:" + hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,138 @@
+package cachesql_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"golift.io/cache"
+	"golift.io/cache/cachesql"
+)
+
+// fakeDB is a database/sql driver with one table of names. SELECT returns every name,
+// and INSERT adds its argument. It counts the queries it runs.
+type fakeDB struct {
+	mu      sync.Mutex
+	names   []string
+	queries int
+}
+
+type (
+	fakeConn struct{ db *fakeDB }
+	fakeStmt struct {
+		db    *fakeDB
+		query string
+	}
+	fakeRows struct{ names []string }
+)
+
+func (f *fakeDB) Open(string) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+func (s *fakeStmt) Close() error              { return nil }
+func (s *fakeStmt) NumInput() int             { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.names = append(s.db.names, args[0].(string)) //nolint:forcetypeassert
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.queries++
+
+	return &fakeRows{names: append([]string{}, s.db.names...)}, nil
+}
+
+func (r *fakeRows) Columns() []string { return []string{"name"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.names) == 0 {
+		return io.EOF
+	}
+
+	dest[0], r.names = r.names[0], r.names[1:]
+
+	return nil
+}
+
+func ExampleNew() {
+	fake := &fakeDB{names: []string{"admin"}}
+	sql.Register("cachesql-example", fake)
+
+	database, _ := sql.Open("cachesql-example", "")
+	defer database.Close()
+
+	queryCache := cache.New(cache.Config{})
+	defer queryCache.Stop(true)
+
+	ctx := context.Background()
+	users := cachesql.New(database, queryCache, 0)
+
+	result, _ := users.Query(ctx, "SELECT name FROM users")
+	result, _ = users.Query(ctx, "SELECT name\n  FROM users;") // same query, cached.
+	fmt.Println(result.Rows, "Queries:", fake.queries)
+
+	_, _ = users.Exec(ctx, "INSERT INTO users (name) VALUES (?)", "guest")
+	result, _ = users.Query(ctx, "SELECT name FROM users")
+	fmt.Println(result.Rows, "Queries:", fake.queries)
+
+	users.Invalidate("USERS")
+	_, _ = users.Query(ctx, "SELECT name FROM users")
+	fmt.Println("Queries:", fake.queries)
+	// Output:
+	// [[admin]] Queries: 1
+	// [[admin] [guest]] Queries: 2
+	// Queries: 3
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"SELECT *\n\tFROM users ;":              "SELECT * FROM users",
+		"SELECT 'a  b'  FROM  users":            "SELECT 'a  b' FROM users",
+		"SELECT \"odd  name\" FROM t WHERE x=1": "SELECT \"odd  name\" FROM t WHERE x=1",
+	}
+
+	for query, expect := range tests {
+		if got := cachesql.Normalize(query); got != expect {
+			t.Errorf("Normalize(%q) = %q, expected %q", query, got, expect)
+		}
+	}
+}
+
+func TestTables(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string][]string{
+		"SELECT * FROM users u JOIN orders o ON u.id = o.user_id":          {"users", "orders"},
+		`SELECT * FROM "Main".Users, x WHERE id IN (SELECT id FROM users)`: {"main.users", "users"},
+		"UPDATE `accounts` SET a = 1":                                      {"accounts"},
+		"DELETE FROM [logs]; INSERT INTO logs VALUES (1)":                  {"logs"},
+		"SELECT 1": nil,
+	}
+
+	for query, expect := range tests {
+		if got := cachesql.Tables(query); !reflect.DeepEqual(got, expect) {
+			t.Errorf("Tables(%q) = %q, expected %q", query, got, expect)
+		}
+	}
+}
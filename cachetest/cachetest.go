@@ -0,0 +1,90 @@
+// Package cachetest provides helpers to test code that uses a golift.io/cache Cache.
+// New returns a cache with a fake Clock, so tests can advance time to prune and expire
+// items instantly and deterministically, instead of sleeping for real durations.
+//
+// Use the clock's time when saving items with an Expire time:
+//
+//	users, clock := cachetest.New(t, cache.Config{PruneInterval: time.Minute})
+//	users.Save("token", "abc", cache.Options{Expire: clock.Now().Add(time.Hour)})
+//	clock.Advance(2 * time.Hour) // the pruner runs and removes the token.
+//	cachetest.AssertKeys(t, users)
+package cachetest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"golift.io/cache"
+)
+
+// New starts a cache with a fake clock, and stops it when the test finishes.
+// The Clock in config is replaced. The clock starts at Start.
+func New(tb testing.TB, config cache.Config) (*cache.Cache, *Clock) {
+	tb.Helper()
+
+	clock := NewClock()
+	config.Clock = clock
+
+	store := cache.New(config)
+	tb.Cleanup(func() { store.Stop(true) })
+
+	return store, clock
+}
+
+// PruneAfter advances the clock, then runs the pruner and returns the number of items pruned.
+// This works if the cache has no PruneInterval. If it does, the pruner may run twice:
+// once because the clock passed the PruneInterval, and once more for this call.
+func PruneAfter(clock *Clock, cache *cache.Cache, d time.Duration) int {
+	clock.Advance(d)
+	return cache.Prune()
+}
+
+// AssertStats reports a test error for every stats counter that does not match want.
+// The durations, latencies and derived ratios are not compared; Size and Gets are.
+func AssertStats(tb testing.TB, cache *cache.Cache, want cache.Stats) {
+	tb.Helper()
+
+	got := cache.Stats()
+	counters := []struct {
+		name      string
+		got, want int64
+	}{
+		{"Size", got.Size, want.Size},
+		{"Gets", got.Gets, want.Gets},
+		{"Hits", got.Hits, want.Hits},
+		{"Misses", got.Misses, want.Misses},
+		{"Saves", got.Saves, want.Saves},
+		{"Updates", got.Updates, want.Updates},
+		{"Deletes", got.Deletes, want.Deletes},
+		{"DelMiss", got.DelMiss, want.DelMiss},
+		{"Pruned", got.Pruned, want.Pruned},
+		{"Expired", got.Expired, want.Expired},
+		{"PrunedUnused", got.PrunedUnused, want.PrunedUnused},
+		{"PrunedPrunable", got.PrunedPrunable, want.PrunedPrunable},
+		{"PrunedFunc", got.PrunedFunc, want.PrunedFunc},
+		{"Evicted", got.Evicted, want.Evicted},
+		{"Replaced", got.Replaced, want.Replaced},
+		{"Prunes", got.Prunes, want.Prunes},
+		{"Refreshes", got.Refreshes, want.Refreshes},
+		{"RefreshErrs", got.RefreshErrs, want.RefreshErrs},
+	}
+
+	for _, counter := range counters {
+		if counter.got != counter.want {
+			tb.Errorf("cache stats %s: got %d, want %d", counter.name, counter.got, counter.want)
+		}
+	}
+}
+
+// AssertKeys reports a test error if the cache does not have exactly these keys, in any order.
+func AssertKeys(tb testing.TB, cache *cache.Cache, want ...string) {
+	tb.Helper()
+
+	want = slices.Clone(want)
+	slices.Sort(want)
+
+	if got := cache.Keys(); !slices.Equal(got, want) {
+		tb.Errorf("cache keys: got %q, want %q", got, want)
+	}
+}
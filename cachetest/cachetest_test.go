@@ -0,0 +1,82 @@
+package cachetest_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"golift.io/cache"
+	"golift.io/cache/cachetest"
+)
+
+func TestPruneInterval(t *testing.T) {
+	t.Parallel()
+
+	users, clock := cachetest.New(t, cache.Config{PruneInterval: time.Minute, PruneAfter: 10 * time.Minute})
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Save("luser", "Under Dawggy", cache.Options{Prune: true})
+	users.Save("token", "abc", cache.Options{Expire: clock.Now().Add(5 * time.Minute)})
+
+	clock.Advance(6 * time.Minute)
+	cachetest.AssertKeys(t, users, "admin", "luser")
+
+	clock.Advance(6 * time.Minute)
+	cachetest.AssertKeys(t, users, "admin")
+	cachetest.AssertStats(t, users, cache.Stats{Size: 1, Saves: 3, Pruned: 2, Expired: 1, PrunedPrunable: 1, Prunes: 2})
+}
+
+func TestRequestAccuracy(t *testing.T) {
+	t.Parallel()
+
+	users, clock := cachetest.New(t, cache.Config{RequestAccuracy: time.Second})
+	users.Save("admin", "Super Dooper", cache.Options{})
+
+	clock.Advance(time.Hour)
+	users.Get("admin")
+
+	item := users.Get("admin")
+	if want := cachetest.Start; !item.Time.Equal(want) {
+		t.Errorf("item time: got %v, want %v", item.Time, want)
+	}
+
+	if want := cachetest.Start.Add(time.Hour); !item.Last.Equal(want) {
+		t.Errorf("item last access: got %v, want %v", item.Last, want)
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	t.Parallel()
+
+	users, clock := cachetest.New(t, cache.Config{})
+	users.Save("token", "abc", cache.Options{Expire: clock.Now().Add(time.Minute)})
+
+	clock.Advance(time.Hour) // no pruner yet.
+	cachetest.AssertKeys(t, users, "token")
+
+	if err := users.Reconfigure(cache.Config{PruneInterval: time.Minute}); err != nil {
+		t.Fatalf("reconfigure: %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	cachetest.AssertKeys(t, users)
+}
+
+func ExamplePruneAfter() {
+	// In a test, use cachetest.New(t, cache.Config{}) to create both of these.
+	clock := cachetest.NewClock()
+	users := cache.New(cache.Config{Clock: clock, MaxUnused: time.Hour})
+	defer users.Stop(true)
+
+	users.Save("admin", "Super Dooper", cache.Options{})
+	users.Save("luser", "Under Dawggy", cache.Options{})
+	users.Get("admin")
+
+	clock.Advance(45 * time.Minute)
+	users.Get("admin")
+
+	fmt.Println("Pruned:", cachetest.PruneAfter(clock, users, 30*time.Minute))
+	fmt.Println("Keys:", users.Keys())
+	// Output:
+	// Pruned: 1
+	// Keys: [admin]
+}
@@ -0,0 +1,137 @@
+package cachetest
+
+import (
+	"sync"
+	"time"
+
+	"golift.io/cache"
+)
+
+// Start is the time a new Clock starts at.
+var Start = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC) //nolint:gochecknoglobals // fixed start time.
+
+// Clock is a fake cache.Clock. Time only moves when Advance or Set is called.
+// Tickers fire once per Advance (or Set) if at least one of their intervals passed,
+// like a *time.Ticker that drops ticks for a slow receiver.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*ticker
+}
+
+// ticker is a fake cache.Ticker created by a Clock.
+type ticker struct {
+	clock   *Clock
+	period  time.Duration
+	next    time.Time
+	ch      chan time.Time
+	stopped chan struct{}
+	stop    sync.Once
+}
+
+// Make sure Clock satisfies the interface.
+var _ cache.Clock = (*Clock)(nil)
+
+// NewClock returns a fake clock set to Start.
+func NewClock() *Clock {
+	return &Clock{now: Start}
+}
+
+// Now returns the clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTicker returns a ticker that fires when the clock is advanced past its interval.
+// Like time.NewTicker, this panics if period is not more than zero.
+func (c *Clock) NewTicker(period time.Duration) cache.Ticker { //nolint:ireturn // implements cache.Clock.
+	if period <= 0 {
+		panic("non-positive interval for cachetest.Clock.NewTicker")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tick := &ticker{
+		clock:   c,
+		period:  period,
+		next:    c.now.Add(period),
+		ch:      make(chan time.Time),
+		stopped: make(chan struct{}),
+	}
+	c.tickers = append(c.tickers, tick)
+
+	return tick
+}
+
+// Advance moves the clock forward and fires the tickers that are due, in the order they were
+// created. Each tick is received by the cache before Advance returns, so a cache request
+// made after Advance returns sees the results of the tick, like items removed by the pruner.
+func (c *Clock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to a time and fires the tickers that are due, like Advance.
+// Setting a time in the past does not fire any tickers.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	c.now = now
+	due := make([]*ticker, 0, len(c.tickers))
+
+	for _, tick := range c.tickers {
+		if now.Before(tick.next) {
+			continue
+		}
+
+		for !now.Before(tick.next) {
+			tick.next = tick.next.Add(tick.period)
+		}
+
+		due = append(due, tick)
+	}
+	c.mu.Unlock()
+
+	// Send outside the lock; the cache may call Now() while handling a tick.
+	for _, tick := range due {
+		select {
+		case tick.ch <- now:
+		case <-tick.stopped:
+		}
+	}
+}
+
+// C returns the ticker's channel.
+func (t *ticker) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stop turns off the ticker. A stopped ticker never fires.
+func (t *ticker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for idx, tick := range t.clock.tickers {
+		if tick == t {
+			t.clock.tickers = append(t.clock.tickers[:idx], t.clock.tickers[idx+1:]...)
+			break
+		}
+	}
+
+	t.stop.Do(func() { close(t.stopped) })
+}
+
+// Reset changes the ticker's interval. The next tick is one interval from the clock's current time.
+func (t *ticker) Reset(period time.Duration) {
+	if period <= 0 {
+		panic("non-positive interval for cachetest ticker Reset")
+	}
+
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.period = period
+	t.next = t.clock.now.Add(period)
+}
@@ -0,0 +1,41 @@
+package cache
+
+import "time"
+
+// Clock provides the time to the cache. The default clock uses the time package.
+// Provide your own clock in Config to control time in tests, so items can be pruned
+// or expired without waiting for real time to pass.
+//   - Now is used for item times, expirations and TTLs.
+//   - NewTicker is used for RequestAccuracy, PruneInterval, StatsInterval and statsd.
+//
+// The cache only reads the time from the tickers' channels after it starts, so a fake
+// clock should send its current time on the channels when time is advanced.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is a ticker returned by a Clock. It works like a *time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// realClock is the default clock. It uses the time package.
+type realClock struct{}
+
+// realTicker wraps a *time.Ticker to satisfy the Ticker interface.
+type realTicker struct {
+	*time.Ticker
+}
+
+// noTicker never fires. It's used for disabled features.
+type noTicker struct{}
+
+func (realClock) Now() time.Time                   { return time.Now() }
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+func (t realTicker) C() <-chan time.Time           { return t.Ticker.C }
+func (noTicker) C() <-chan time.Time               { return nil }
+func (noTicker) Stop()                             {}
+func (noTicker) Reset(time.Duration)               {}
@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// EventReason describes why an Event was emitted.
+type EventReason uint8
+
+// These are the reasons an Event is emitted.
+const (
+	EventSave   EventReason = iota + 1 // A new key was saved.
+	EventUpdate                        // An existing key was saved again or modified.
+	EventDelete                        // A key was deleted (or flushed).
+	EventPrune                         // A key was pruned because it was unused for too long.
+	EventExpire                        // A key was pruned because its Expire time passed.
+	EventEvict                         // A key was evicted to reduce memory use.
+)
+
+// eventBuffer is the size of each subscriber's channel buffer.
+const eventBuffer = 1024
+
+// Event is sent to subscribers when the cache is mutated.
+//   - Key is the item's key. Namespace subscribers get the key without the prefix.
+//   - Time is when the event happened, as accurate as Config.RequestAccuracy.
+//   - Saved is when the item was saved (or updated); it's the same as Item.Time.
+//   - Last is the time when the last cache get for this item occurred.
+type Event struct {
+	Reason EventReason `json:"reason"`
+	Key    string      `json:"key"`
+	Time   time.Time   `json:"time"`
+	Saved  time.Time   `json:"saved"`
+	Last   time.Time   `json:"lastAccess"`
+}
+
+// subscriber is a channel receiving events for keys with a prefix.
+type subscriber struct {
+	events chan Event
+	prefix string
+}
+
+// watcher is a channel receiving item copies for a single key.
+type watcher struct {
+	ctx   context.Context //nolint:containedctx // watchers are closed when their context is done.
+	items chan *Item
+}
+
+// String turns an event reason into a human readable word.
+func (e EventReason) String() string {
+	switch e {
+	case EventSave:
+		return "save"
+	case EventUpdate:
+		return "update"
+	case EventDelete:
+		return "delete"
+	case EventPrune:
+		return "prune"
+	case EventExpire:
+		return "expire"
+	case EventEvict:
+		return "evict"
+	default:
+		return "unknown"
+	}
+}
+
+// Subscribe returns a channel that receives an Event every time an item is saved,
+// updated, deleted, pruned, expired or evicted. When called on a namespace, only events for
+// keys in that namespace are sent. The channel is buffered, and events are dropped
+// if the buffer is full, so the cache processor never blocks on a slow subscriber.
+// Pass the channel to Unsubscribe() to stop receiving events and close it.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Subscribe() <-chan Event {
+	sub := &subscriber{events: make(chan Event, eventBuffer), prefix: c.ns}
+
+	c.send(&req{do: func(string, time.Time) *Item {
+		c.subs = append(c.subs, sub)
+		return nil
+	}})
+
+	return sub.events
+}
+
+// Unsubscribe stops sending events to a channel returned by Subscribe(), and closes it.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Unsubscribe(events <-chan Event) {
+	c.send(&req{do: func(string, time.Time) *Item {
+		for idx, sub := range c.subs {
+			if sub.events == events {
+				close(sub.events)
+				c.subs = append(c.subs[:idx], c.subs[idx+1:]...)
+
+				break
+			}
+		}
+
+		return nil
+	}})
+}
+
+// Watch returns a channel that receives a copy of an item every time its key is saved
+// or updated. A nil item is sent when the key is deleted, pruned or expired. The channel
+// is buffered, and items are dropped if the buffer is full, so the cache processor never
+// blocks on a slow watcher. The channel is closed when the cache stops, or when ctx is
+// done; the processor notices a done context within Config.RequestAccuracy.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Watch(ctx context.Context, requestKey string) <-chan *Item {
+	watch := &watcher{ctx: ctx, items: make(chan *Item, eventBuffer)}
+
+	c.send(&req{key: requestKey, do: func(key string, _ time.Time) *Item {
+		c.watchers[key] = append(c.watchers[key], watch)
+		return nil
+	}})
+
+	return watch.items
+}
+
+// unwatch closes and removes watchers with a done context, or all of them if all is true.
+// This runs inside the processor.
+func (c *Cache) unwatch(all bool) {
+	for key, watchers := range c.watchers {
+		keep := watchers[:0]
+
+		for _, watch := range watchers {
+			if all || watch.ctx.Err() != nil {
+				close(watch.items)
+			} else {
+				keep = append(keep, watch)
+			}
+		}
+
+		if len(keep) == 0 {
+			delete(c.watchers, key)
+		} else {
+			c.watchers[key] = keep
+		}
+	}
+}
+
+// emit sends an event to every subscriber for the key, and the item to its watchers.
+// This runs inside the processor.
+// The EvictionPolicy, if there is one, is told about the change too.
+func (c *Cache) emit(reason EventReason, key string, item *Item, now time.Time) {
+	if policy := c.conf.Eviction; policy != nil {
+		if (reason == EventSave || reason == EventUpdate) && !item.opts.Pin {
+			policy.OnSave(key)
+		} else {
+			policy.OnDelete(key)
+		}
+	}
+
+	if reason == EventSave || reason == EventUpdate {
+		c.missFilter.remove(key)
+		c.reindex(key, item)
+		c.journalItem(key, item, now)
+		c.writeThrough(key, item)
+	} else {
+		c.unindex(key)
+		c.journalItem(key, nil, now)
+	}
+
+	if watchers := c.watchers[key]; len(watchers) > 0 {
+		var watched *Item
+		if reason == EventSave || reason == EventUpdate {
+			watched = c.copy(item)
+		}
+
+		for _, watch := range watchers {
+			select {
+			case watch.items <- watched:
+			default: // Watcher is not keeping up; drop the item.
+			}
+		}
+	}
+
+	for _, sub := range c.subs {
+		if !strings.HasPrefix(key, sub.prefix) {
+			continue
+		}
+
+		select {
+		case sub.events <- Event{
+			Reason: reason,
+			Key:    strings.TrimPrefix(key, sub.prefix),
+			Time:   now,
+			Saved:  item.Time,
+			Last:   item.Last,
+		}:
+		default: // Subscriber is not keeping up; drop the event.
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"container/heap"
+	"time"
+)
+
+// minimumExpiryCompact is the smallest expiry heap that is compacted when it has too many stale entries.
+const minimumExpiryCompact = 1024
+
+// expiry is an item with an Expire time in the expiry heap.
+// An entry is stale if the key now has another item, or the item's Expire time changed.
+type expiry struct {
+	key    string
+	item   *Item
+	expire time.Time
+}
+
+// expiryHeap holds items with an Expire time, soonest first, so the pruner can
+// remove expired items without checking every key in the cache.
+type expiryHeap []expiry
+
+// expireLater adds an item to the expiry heap if it has an Expire time.
+// This runs inside the processor every time an item is saved or its Expire time changes.
+func (c *Cache) expireLater(key string, item *Item) {
+	if item.opts == nil || item.opts.Expire.IsZero() {
+		return
+	}
+
+	heap.Push(&c.expiry, expiry{key: key, item: item, expire: item.opts.Expire})
+
+	// Items that are replaced or deleted before they expire leave stale entries behind.
+	if len(c.expiry) > minimumExpiryCompact && len(c.expiry) > 2*c.cache.Len() {
+		c.compactExpiry()
+	}
+}
+
+// expire prunes every item in the expiry heap that expired before from.
+func (c *Cache) expire(from *time.Time) {
+	for len(c.expiry) > 0 && c.expiry[0].expire.Before(*from) {
+		entry := heap.Pop(&c.expiry).(expiry) //nolint:forcetypeassert
+
+		if c.current(&entry) {
+			c.pruneItem(from, entry.key, entry.item)
+		}
+	}
+}
+
+// current returns true if an expiry heap entry is not stale.
+func (c *Cache) current(entry *expiry) bool {
+	return c.cache.Get(entry.key) == entry.item && entry.item.opts != nil && entry.item.opts.Expire.Equal(entry.expire)
+}
+
+// compactExpiry removes the stale entries from the expiry heap.
+func (c *Cache) compactExpiry() {
+	entries := c.expiry[:0]
+
+	for idx := range c.expiry {
+		if c.current(&c.expiry[idx]) {
+			entries = append(entries, c.expiry[idx])
+		}
+	}
+
+	clear(c.expiry[len(entries):]) // release the stale items.
+	c.expiry = entries
+	heap.Init(&c.expiry)
+}
+
+// expiryOnly returns true if the pruner only needs to remove expired items.
+// The pruner does not check every key when no item can be unused for too long,
+// and no item can be refreshed.
+func (c *Cache) expiryOnly() bool {
+	return c.conf.MaxUnused == Forever && c.conf.PruneAfter == Forever && c.conf.Refresher == nil
+}
+
+func (e expiryHeap) Len() int           { return len(e) }
+func (e expiryHeap) Less(i, j int) bool { return e[i].expire.Before(e[j].expire) }
+func (e expiryHeap) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e *expiryHeap) Push(x any)        { *e = append(*e, x.(expiry)) } //nolint:forcetypeassert
+func (e *expiryHeap) Pop() any {
+	old := *e
+	last := old[len(old)-1]
+	old[len(old)-1] = expiry{} // release the item.
+	*e = old[:len(old)-1]
+
+	return last
+}
@@ -0,0 +1,321 @@
+package cache
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Codec encodes and decodes the items written by Export and read by Import.
+// Encode is called once with a *[]Entry, and Decode once with a pointer to an empty []Entry.
+// Use GobCodec, JSONCodec, or write your own around another encoding.
+type Codec interface {
+	Encode(w io.Writer, v any) error
+	Decode(r io.Reader, v any) error
+}
+
+// GobCodec is a Codec that uses encoding/gob. Cached Data is stored in an interface, so
+// gob.Register() must be called with every type of data the cache holds, except for
+// Go's basic types (like string, int and []byte) that gob registers itself.
+type GobCodec struct{}
+
+// JSONCodec is a Codec that uses encoding/json, so exports can be read by people and other tools.
+// Set Indent to write indented JSON. Imported Data has the types encoding/json decodes into an
+// interface: float64 for numbers, string, bool, []any and map[string]any. Use GobCodec, or your
+// own Codec, to import other types.
+type JSONCodec struct {
+	Indent string
+}
+
+// Entry is an item written by Export and read by Import, with its key and the Options
+// it was saved with, so it can be restored as it was. Negative items are exported with
+// the error message as Data, and imported with an error made from it.
+type Entry struct {
+	Key      string    `json:"key"`
+	Data     any       `json:"data"`
+	Time     time.Time `json:"created"`
+	Last     time.Time `json:"lastAccess"`
+	Hits     int64     `json:"hits"`
+	Negative bool      `json:"negative,omitempty"`
+	Version  int64     `json:"version"`
+	Options  Options   `json:"options"`
+}
+
+// Make sure the codecs satisfy the interface.
+var (
+	_ Codec = GobCodec{}
+	_ Codec = JSONCodec{}
+)
+
+// Encode writes v with a gob encoder.
+func (GobCodec) Encode(w io.Writer, v any) error {
+	return gob.NewEncoder(w).Encode(v) //nolint:wrapcheck // Export wraps it.
+}
+
+// Decode reads v with a gob decoder.
+func (GobCodec) Decode(r io.Reader, v any) error {
+	return gob.NewDecoder(r).Decode(v) //nolint:wrapcheck // Import wraps it.
+}
+
+// Encode writes v as JSON.
+func (j JSONCodec) Encode(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", j.Indent)
+
+	return encoder.Encode(v) //nolint:wrapcheck // Export wraps it.
+}
+
+// Decode reads v from JSON.
+func (JSONCodec) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v) //nolint:wrapcheck // Import wraps it.
+}
+
+// ExportOptions are used to export and import a cache with ExportWithOptions and ImportWithOptions.
+// Import with the same options the cache was exported with.
+type ExportOptions struct {
+	// Codec encodes the items. @default GobCodec
+	Codec Codec
+	// Compression compresses the encoded items, like Gzip. @default nil, not compressed.
+	Compression Compression
+}
+
+// Compression compresses exports and decompresses imports. Use Gzip, or wrap another
+// compression library, like zstd from github.com/klauspost/compress:
+//
+//	func (Zstd) NewWriter(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }
+//	func (Zstd) NewReader(r io.Reader) (io.ReadCloser, error) {
+//		decoder, err := zstd.NewReader(r)
+//		return decoder.IOReadCloser(), err
+//	}
+type Compression interface {
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Gzip is a Compression that uses compress/gzip at a compression Level, like gzip.BestSpeed.
+// A zero Level uses gzip.DefaultCompression.
+type Gzip struct {
+	Level int
+}
+
+// Make sure Gzip satisfies the interface.
+var _ Compression = Gzip{}
+
+// NewWriter returns a gzip writer.
+func (g Gzip) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	level := g.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	return gzip.NewWriterLevel(w, level) //nolint:wrapcheck // ExportWithOptions wraps it.
+}
+
+// NewReader returns a gzip reader.
+func (Gzip) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r) //nolint:wrapcheck // ImportWithOptions wraps it.
+}
+
+// Export writes every item in the cache, or in the namespace, to w with codec, sorted by key.
+// Items are copied inside the processor, like List, and encoded after, so a slow writer does
+// not hold up other requests. Keys in a namespace are written without the namespace prefix.
+// This returns ErrStopped if the cache is not running.
+func (c *Cache) Export(w io.Writer, codec Codec) error {
+	return c.ExportWithOptions(w, ExportOptions{Codec: codec})
+}
+
+// ExportWithOptions is like Export, with options to choose the codec and compress the export.
+func (c *Cache) ExportWithOptions(w io.Writer, opts ExportOptions) error {
+	var entries []Entry
+
+	if c.send(&req{do: func(prefix string, _ time.Time) *Item {
+		entries = c.entries(prefix)
+		return &Item{}
+	}}) == nil {
+		return ErrStopped
+	}
+
+	return encodeEntries(w, entries, opts)
+}
+
+// encodeEntries writes entries to w with the codec and compression in opts.
+func encodeEntries(w io.Writer, entries []Entry, opts ExportOptions) error {
+	if opts.Codec == nil {
+		opts.Codec = GobCodec{}
+	}
+
+	if opts.Compression == nil {
+		if err := opts.Codec.Encode(w, &entries); err != nil {
+			return fmt.Errorf("exporting cache: %w", err)
+		}
+
+		return nil
+	}
+
+	compressor, err := opts.Compression.NewWriter(w)
+	if err != nil {
+		return fmt.Errorf("exporting cache: compressing: %w", err)
+	}
+
+	if err := opts.Codec.Encode(compressor, &entries); err != nil {
+		compressor.Close() //nolint:errcheck // the encoding error is returned.
+		return fmt.Errorf("exporting cache: %w", err)
+	}
+
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("exporting cache: compressing: %w", err)
+	}
+
+	return nil
+}
+
+// Import reads items written by Export from r with codec, and saves them in the cache,
+// or in the namespace, with the times, hits, version and options they were exported with.
+// Existing keys are replaced, and items that expired since they were exported are skipped.
+// Imported items count as saves and updates in stats, and send save and update events.
+// Items are decoded before any are saved; nothing is saved if decoding fails.
+// This returns ErrStopped if the cache is not running.
+func (c *Cache) Import(r io.Reader, codec Codec) error {
+	return c.ImportWithOptions(r, ExportOptions{Codec: codec})
+}
+
+// ImportWithOptions is like Import, for items exported with ExportWithOptions.
+func (c *Cache) ImportWithOptions(r io.Reader, opts ExportOptions) error {
+	entries, err := decodeEntries(r, opts)
+	if err != nil {
+		return err
+	}
+
+	if c.send(&req{do: func(prefix string, now time.Time) *Item {
+		for idx := range entries {
+			c.restore(prefix, &entries[idx], now)
+		}
+
+		return &Item{}
+	}}) == nil {
+		return ErrStopped
+	}
+
+	return nil
+}
+
+// decodeEntries reads entries from r with the codec and compression in opts.
+func decodeEntries(r io.Reader, opts ExportOptions) ([]Entry, error) {
+	var entries []Entry
+
+	if opts.Codec == nil {
+		opts.Codec = GobCodec{}
+	}
+
+	if opts.Compression != nil {
+		decompressor, err := opts.Compression.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("importing cache: decompressing: %w", err)
+		}
+		defer decompressor.Close() //nolint:errcheck // nothing is written.
+
+		r = decompressor
+	}
+
+	if err := opts.Codec.Decode(r, &entries); err != nil {
+		return nil, fmt.Errorf("importing cache: %w", err)
+	}
+
+	return entries, nil
+}
+
+// entries returns copies of the items with a prefix, sorted by key. This runs inside the processor.
+func (c *Cache) entries(prefix string) []Entry {
+	entries := make([]Entry, 0, c.cache.Len())
+
+	c.cache.Iterate(func(key string, item *Item) bool {
+		if strings.HasPrefix(key, prefix) {
+			entries = append(entries, c.entry(prefix, key, item))
+		}
+
+		return true
+	})
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	return entries
+}
+
+// entry returns a copy of an item, with the prefix trimmed from its key. This runs inside the processor.
+func (c *Cache) entry(prefix, key string, item *Item) Entry {
+	dupe := c.copy(item)
+	entry := Entry{
+		Key:      strings.TrimPrefix(key, prefix),
+		Data:     dupe.Data,
+		Time:     dupe.Time,
+		Last:     dupe.Last,
+		Hits:     dupe.Hits,
+		Negative: dupe.Negative,
+		Version:  dupe.Version,
+		Options:  *item.opts,
+	}
+	entry.Options.Meta = dupe.Meta
+
+	if err, ok := dupe.Data.(error); ok && dupe.Negative {
+		entry.Data = err.Error()
+	}
+
+	return entry
+}
+
+// restore saves an imported entry with a prefix. This runs inside the processor.
+func (c *Cache) restore(prefix string, entry *Entry, now time.Time) {
+	if expire := entry.Options.Expire; !expire.IsZero() && now.After(expire) {
+		return
+	}
+
+	key, reason := prefix+entry.Key, EventSave
+	c.unspill(key, now) // the imported item replaces the stored item.
+
+	if c.cache.Get(key) != nil {
+		reason = EventUpdate
+		c.stats.Updates++
+		c.stats.pruned(replaced)
+	} else {
+		c.stats.Saves++
+	}
+
+	item := c.load(key, entry)
+	c.expireLater(key, item)
+	c.emit(reason, key, item, now)
+}
+
+// load stores an entry in the cache as an item, and returns the item.
+// This does not count stats or send events. This runs inside the processor.
+func (c *Cache) load(key string, entry *Entry) *Item {
+	opts := entry.Options
+	item := &Item{
+		Data:     entry.Data,
+		Time:     entry.Time,
+		Last:     entry.Last,
+		Hits:     entry.Hits,
+		Negative: entry.Negative,
+		Version:  entry.Version,
+		opts:     &opts,
+	}
+
+	if msg, ok := entry.Data.(string); ok && entry.Negative {
+		item.Data = errors.New(msg) //nolint:err113 // the original error can not be restored.
+	}
+
+	// Keep versions unique, so a version from before the import never matches a new save.
+	if c.version = max(c.version, entry.Version); item.Version == 0 {
+		c.version++
+		item.Version = c.version
+	}
+
+	c.cache.Set(key, item)
+
+	return item
+}
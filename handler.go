@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// handlerItem describes a cached item in the Handler() output. Values are never included.
+type handlerItem struct {
+	Key     string     `json:"key"`
+	Age     Duration   `json:"age"`
+	Idle    Duration   `json:"idle"`
+	Hits    int64      `json:"hits"`
+	Prune   bool       `json:"prune"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// handlerOutput is the Handler() response body.
+type handlerOutput struct {
+	Stats *Stats         `json:"stats"`
+	Items []*handlerItem `json:"items,omitempty"`
+}
+
+// Handler returns an http.Handler that serves the cache stats as JSON. Mount it under
+// a path like /debug/cache. Add ?items=true to the request to include a list of every
+// key with its age, idle time, hits and prune settings; cached values are never included.
+// When called on a namespace, only the namespace's stats and items are served.
+func (c *Cache) Handler() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		output := &handlerOutput{Stats: c.Stats()}
+
+		if items, _ := strconv.ParseBool(req.URL.Query().Get("items")); items {
+			output.Items = c.handlerItems()
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(resp).Encode(output); err != nil {
+			http.Error(resp, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// handlerItems builds the item list inside the processor, so values are not copied.
+func (c *Cache) handlerItems() []*handlerItem {
+	var items []*handlerItem
+
+	c.send(&req{do: func(prefix string, now time.Time) *Item {
+		items = make([]*handlerItem, 0, c.count(prefix))
+
+		c.cache.Iterate(func(key string, item *Item) bool {
+			if !strings.HasPrefix(key, prefix) {
+				return true
+			}
+
+			listed := &handlerItem{
+				Key:   strings.TrimPrefix(key, prefix),
+				Age:   Duration{now.Sub(item.Time)},
+				Idle:  Duration{now.Sub(item.Last)},
+				Hits:  item.Hits,
+				Prune: item.opts.prunable(),
+			}
+
+			if expire := item.opts.Expire; !expire.IsZero() {
+				listed.Expires = &expire
+			}
+
+			items = append(items, listed)
+
+			return true
+		})
+
+		return nil
+	}})
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+
+	return items
+}
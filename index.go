@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// GetByIndex returns copies of the items whose value in a secondary index is value,
+// sorted by key. Indexes are set with Config.Indexes, and kept up to date inside the
+// processor, so this does not scan the cache. Expired items that were not pruned yet,
+// and items stored in the Overflow, are not returned. This returns an empty slice for
+// an index that does not exist. When called on a namespace, only items in that namespace
+// are returned. This procedure does NOT update hit/miss stats like cache.Get() does.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) GetByIndex(index, value string) []*Item {
+	items := []*Item{}
+
+	c.send(&req{do: func(prefix string, now time.Time) *Item {
+		keys := make([]string, 0, len(c.indexes[index][value]))
+		for key := range c.indexes[index][value] {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if item := c.cache.Get(key); item != nil &&
+				(item.opts.Expire.IsZero() || !now.After(item.opts.Expire)) {
+				items = append(items, c.copy(item))
+			}
+		}
+
+		return nil
+	}})
+
+	return items
+}
+
+// reindex updates the secondary indexes for a saved or updated item. This runs inside the processor.
+func (c *Cache) reindex(key string, item *Item) {
+	if len(c.conf.Indexes) == 0 {
+		return
+	}
+
+	c.unindex(key)
+
+	values := make(map[string]string, len(c.conf.Indexes))
+
+	for index, indexFn := range c.conf.Indexes {
+		value := indexFn(key, item)
+		if value == "" {
+			continue
+		}
+
+		if c.indexes[index] == nil {
+			c.indexes[index] = make(map[string]map[string]struct{})
+		}
+
+		if c.indexes[index][value] == nil {
+			c.indexes[index][value] = make(map[string]struct{})
+		}
+
+		c.indexes[index][value][key] = struct{}{}
+		values[index] = value
+	}
+
+	c.indexed[key] = values
+}
+
+// unindex removes a key from the secondary indexes. This runs inside the processor.
+func (c *Cache) unindex(key string) {
+	for index, value := range c.indexed[key] {
+		delete(c.indexes[index][value], key)
+
+		if len(c.indexes[index][value]) == 0 {
+			delete(c.indexes[index], value)
+		}
+	}
+
+	delete(c.indexed, key)
+}
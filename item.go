@@ -0,0 +1,32 @@
+package cache
+
+import "time"
+
+// The helpers in this file use the time package, not Config.Clock. Items returned
+// by a cache with a fake clock should compare their times to the fake clock instead.
+
+// Age returns how long ago the item was saved or updated, or 0 if Time is zero.
+func (i *Item) Age() time.Duration {
+	if i.Time.IsZero() {
+		return 0
+	}
+
+	return time.Since(i.Time)
+}
+
+// Idle returns how long ago the item was last retrieved, or 0 if Last is zero.
+// Items are not retrieved when they're saved, but Last is set to the save time.
+func (i *Item) Idle() time.Duration {
+	if i.Last.IsZero() {
+		return 0
+	}
+
+	return time.Since(i.Last)
+}
+
+// Expired returns true if the item has an Expire time and it has passed.
+// Get does not return expired items, but copies from List, and copies kept after they
+// were returned, may expire.
+func (i *Item) Expired() bool {
+	return !i.Expires.IsZero() && time.Now().After(i.Expires)
+}
@@ -0,0 +1,250 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// JournalConfig enables a write-ahead journal. Every save, update and delete is appended
+// to a file, and the file is replayed when the cache first starts, so items survive a
+// restart or a crash without periodic snapshots. The journal is compacted every time the
+// cache starts: it's rewritten with only the current items. Retrievals are not journaled,
+// so restored items keep the hits and last access time they had when they were last saved.
+// Writes happen inside the cache processor, so a slow disk slows down every request.
+type JournalConfig struct {
+	// Path is the journal file. It's created if it does not exist.
+	Path string
+	// SyncEvery controls how often the journal is flushed to disk with fsync.
+	// 0 syncs after every write, so no acknowledged write is lost in a crash.
+	// More than 0 syncs at most this often, checked every RequestAccuracy, so a crash
+	// loses up to this much. Forever never syncs, and lets the operating system decide.
+	// @default 0, sync every write.
+	SyncEvery time.Duration
+	// Codec encodes the journal records. @default GobCodec
+	Codec Codec
+}
+
+// journalHeader is the size of a record header: the payload length and its CRC-32.
+const journalHeader = 8
+
+// journalRecord is a record in the journal file. Saved items have the whole Entry;
+// deleted items only have the Key.
+type journalRecord struct {
+	Delete bool  `json:"delete,omitempty"`
+	Entry  Entry `json:"entry"`
+}
+
+// journal is the open journal file.
+type journal struct {
+	file   *os.File
+	conf   *JournalConfig
+	buf    bytes.Buffer // reused to encode records.
+	dirty  bool         // written to since the last sync.
+	synced time.Time
+}
+
+// openJournal replays the journal if replay is true, then compacts it and opens it to
+// append new records. The journal is disabled if there's an error.
+// This runs in start(), before the processor starts.
+func (c *Cache) openJournal(now time.Time, replay bool) {
+	if c.conf.Journal == nil {
+		return
+	}
+
+	if replay {
+		if err := c.replay(now); err != nil {
+			c.log(slog.LevelError, "cache journal disabled", "error", err)
+			return
+		}
+	}
+
+	file, err := c.compactJournal()
+	if err != nil {
+		c.log(slog.LevelError, "cache journal disabled", "error", err)
+		return
+	}
+
+	c.journal = &journal{file: file, conf: c.conf.Journal, synced: now}
+}
+
+// replay loads the items in the journal file into the cache. A partial record at the
+// end of the file, from a crash during a write, is ignored.
+func (c *Cache) replay(now time.Time) error {
+	file, err := os.Open(c.conf.Journal.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("opening journal: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	header := make([]byte, journalHeader)
+
+	for count := 0; ; count++ {
+		if _, err := io.ReadFull(reader, header); errors.Is(err, io.EOF) {
+			c.log(slog.LevelInfo, "replayed cache journal", "records", count, "size", c.cache.Len())
+			return nil
+		} else if err != nil {
+			c.log(slog.LevelWarn, "cache journal ends with a partial record", "records", count)
+			return nil //nolint:nilerr // the write was not finished, so it was not acknowledged.
+		}
+
+		payload := make([]byte, binary.BigEndian.Uint32(header))
+		if _, err := io.ReadFull(reader, payload); err != nil ||
+			crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+			c.log(slog.LevelWarn, "cache journal ends with a partial record", "records", count)
+			return nil
+		}
+
+		var record journalRecord
+		if err := c.conf.Journal.Codec.Decode(bytes.NewReader(payload), &record); err != nil {
+			return fmt.Errorf("decoding journal record %d: %w", count, err)
+		}
+
+		if record.Delete {
+			c.cache.Delete(record.Entry.Key)
+		} else if expire := record.Entry.Options.Expire; expire.IsZero() || !now.After(expire) {
+			c.load(record.Entry.Key, &record.Entry)
+		}
+	}
+}
+
+// compactJournal writes the current items to a new journal file, replaces the old file
+// with it, and returns the new file opened to append.
+func (c *Cache) compactJournal() (*os.File, error) {
+	path := c.conf.Journal.Path
+	compact := &journal{conf: c.conf.Journal}
+
+	var err error
+	if compact.file, err = os.OpenFile(path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600); err != nil {
+		return nil, fmt.Errorf("compacting journal: %w", err)
+	}
+
+	c.cache.Iterate(func(key string, item *Item) bool {
+		if err = compact.write(&journalRecord{Entry: c.entry("", key, item)}); err != nil {
+			return false
+		}
+
+		return true
+	})
+
+	if err == nil {
+		err = compact.file.Sync()
+	}
+
+	if closeErr := compact.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("compacting journal: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening journal: %w", err)
+	}
+
+	return file, nil
+}
+
+// write appends a record to the journal file.
+func (j *journal) write(record *journalRecord) error {
+	j.buf.Reset()
+	j.buf.Write(make([]byte, journalHeader)) // filled in after the payload is encoded.
+
+	if err := j.conf.Codec.Encode(&j.buf, record); err != nil {
+		return fmt.Errorf("encoding journal record: %w", err)
+	}
+
+	data := j.buf.Bytes()
+	binary.BigEndian.PutUint32(data, uint32(len(data)-journalHeader)) //nolint:gosec // records are smaller than 4GB.
+	binary.BigEndian.PutUint32(data[4:], crc32.ChecksumIEEE(data[journalHeader:]))
+
+	if _, err := j.file.Write(data); err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+
+	j.dirty = true
+
+	return nil
+}
+
+// sync flushes the journal to disk if it was written to, and force is true or SyncEvery passed.
+func (j *journal) sync(now time.Time, force bool) error {
+	if !j.dirty || j.conf.SyncEvery == Forever || (!force && now.Sub(j.synced) < j.conf.SyncEvery) {
+		return nil
+	}
+
+	j.dirty, j.synced = false, now
+
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("syncing journal: %w", err)
+	}
+
+	return nil
+}
+
+// journalItem appends a saved item, or a deleted key if item is nil, to the journal.
+// This runs inside the processor.
+func (c *Cache) journalItem(key string, item *Item, now time.Time) {
+	if c.journal == nil {
+		return
+	}
+
+	record := &journalRecord{Delete: item == nil, Entry: Entry{Key: key}}
+	if item != nil {
+		record.Entry = c.entry("", key, item)
+	}
+
+	err := c.journal.write(record)
+	if err == nil {
+		err = c.journal.sync(now, false)
+	}
+
+	if err != nil {
+		c.log(slog.LevelError, "cache journal write failed", "key", key, "error", err)
+	}
+}
+
+// syncJournal syncs the journal if SyncEvery passed. This runs inside the processor.
+func (c *Cache) syncJournal(now time.Time) {
+	if c.journal == nil {
+		return
+	}
+
+	if err := c.journal.sync(now, false); err != nil {
+		c.log(slog.LevelError, "cache journal sync failed", "error", err)
+	}
+}
+
+// closeJournal syncs and closes the journal when the processor stops.
+func (c *Cache) closeJournal(now time.Time) {
+	if c.journal == nil {
+		return
+	}
+
+	err := c.journal.sync(now, true)
+	if closeErr := c.journal.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		c.log(slog.LevelError, "cache journal close failed", "error", err)
+	}
+
+	c.journal = nil
+}
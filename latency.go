@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"math/bits"
+	"time"
+)
+
+// histogramSub is the number of buckets for each power of two nanoseconds.
+// Four sub-buckets keep percentile estimates within about 12% of the real value.
+const (
+	histogramSubBits = 2
+	histogramSub     = 1 << histogramSubBits
+	histogramBuckets = 64 * histogramSub
+)
+
+// Latency has percentiles of request durations, estimated from a histogram.
+type Latency struct {
+	P50 Duration `json:"p50"`
+	P95 Duration `json:"p95"`
+	P99 Duration `json:"p99"`
+	Max Duration `json:"max"`
+}
+
+// histogram counts durations in log-linear buckets.
+type histogram struct {
+	counts [histogramBuckets]int64
+	total  int64
+	max    time.Duration
+}
+
+// add a duration to the histogram.
+func (h *histogram) add(dur time.Duration) {
+	if dur < 0 {
+		dur = 0
+	}
+
+	h.counts[bucket(dur)]++
+	h.total++
+	h.max = max(h.max, dur)
+}
+
+// latency returns the percentiles for the durations in the histogram.
+func (h *histogram) latency() Latency {
+	return Latency{
+		P50: Duration{h.percentile(0.50)}, //nolint:mnd // 50th percentile.
+		P95: Duration{h.percentile(0.95)}, //nolint:mnd // 95th percentile.
+		P99: Duration{h.percentile(0.99)}, //nolint:mnd // 99th percentile.
+		Max: Duration{h.max},
+	}
+}
+
+// percentile returns the middle of the bucket that contains the percentile.
+func (h *histogram) percentile(fraction float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	target := int64(float64(h.total)*fraction + 0.5) //nolint:mnd // round.
+	target = max(target, 1)
+
+	var count int64
+
+	for idx, buckets := range h.counts {
+		if count += buckets; count >= target {
+			low, high := bucketRange(idx)
+			return min((low+high)/2, h.max) //nolint:mnd // middle of the bucket.
+		}
+	}
+
+	return h.max
+}
+
+// bucket returns the histogram bucket for a duration.
+func bucket(dur time.Duration) int {
+	nanos := uint64(dur)
+	if nanos < histogramSub {
+		return int(nanos)
+	}
+
+	// The power of two, and the next bits after the leading one.
+	power := bits.Len64(nanos) - 1
+	sub := (nanos >> (power - histogramSubBits)) & (histogramSub - 1)
+
+	return (power-histogramSubBits+1)*histogramSub + int(sub)
+}
+
+// bucketRange returns the smallest and largest duration in a bucket.
+func bucketRange(idx int) (time.Duration, time.Duration) {
+	if idx < histogramSub {
+		return time.Duration(idx), time.Duration(idx)
+	}
+
+	power := idx/histogramSub + histogramSubBits - 1
+	sub := uint64(idx % histogramSub)
+	low := uint64(1)<<power | sub<<(power-histogramSubBits)
+	high := low + uint64(1)<<(power-histogramSubBits) - 1
+
+	return time.Duration(low), time.Duration(high) //nolint:gosec // durations fit.
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Loader is called by Get, GetData and Load when a key is not in the cache. The key includes
+// the namespace prefix, if any. The returned data is saved with the returned Options, and
+// returned to the caller, unless the key was saved while the Loader was running. Returning nil data means the key does not exist; nothing is saved.
+// Errors are not cached; use SaveNegative in the Loader to cache them.
+type Loader func(ctx context.Context, key string) (any, Options, error)
+
+// Load returns an item like Get does. If the key is not in the cache, and Config.Loader is set,
+// the Loader is called in this go routine, and the result is saved and returned.
+// Concurrent loads for the same key are coalesced, so the Loader runs once per key at a time.
+// This returns nil and no error if the key does not exist, and the Loader's error if it fails.
+// If the Loader panics, the panic is passed up to its caller, and callers waiting for the
+// same key get an ErrLoaderPanic error.
+func (c *Cache) Load(ctx context.Context, requestKey string) (*Item, error) {
+	if c.missFilter.has(c.ns + requestKey) {
+		return nil, nil //nolint:nilnil // the key does not exist.
+	}
+
+	if item := c.send(&req{key: requestKey, get: true}); item != nil || c.conf.Loader == nil {
+		return item, nil
+	}
+
+	return c.loadMissing(ctx, requestKey)
+}
+
+// readThrough loads a missing key for Get and GetData, and logs Loader errors.
+func (c *Cache) readThrough(requestKey string) *Item {
+	item, err := c.loadMissing(context.Background(), requestKey)
+	if err != nil {
+		c.log(slog.LevelWarn, "cache loader failed", "key", c.ns+requestKey, "error", err)
+	}
+
+	return item
+}
+
+// loadMissing calls the Loader for a missing key, or waits for the call already running, and
+// saves the result. The item is saved and copied inside the processor without counting
+// a hit, because the Get that missed was already counted. If the key was saved while the
+// Loader was running, the saved item is kept and returned instead of the loaded data.
+func (c *Cache) loadMissing(ctx context.Context, requestKey string) (*Item, error) {
+	key := c.ns + requestKey
+
+	c.loadMu.Lock()
+	if running, ok := c.loads[key]; ok {
+		c.loadMu.Unlock()
+		running.wait.Wait()
+
+		if running.data == nil {
+			return nil, running.err
+		}
+
+		dupe := *running.data // each caller gets its own copy.
+
+		return &dupe, running.err
+	}
+
+	running := &call[*Item]{}
+	running.wait.Add(1)
+	c.loads[key] = running
+	c.loadMu.Unlock()
+
+	// Release the waiting callers even if the Loader panics.
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			running.err = fmt.Errorf("%w: %v", ErrLoaderPanic, recovered)
+			defer panic(recovered)
+		}
+
+		c.loadMu.Lock()
+		delete(c.loads, key)
+		c.loadMu.Unlock()
+		running.wait.Done()
+	}()
+
+	data, opts, err := c.conf.Loader(ctx, key)
+	if err != nil {
+		running.err = fmt.Errorf("loading %s: %w", key, err)
+		return nil, running.err
+	} else if data == nil {
+		c.filterMiss(requestKey)
+		return nil, nil //nolint:nilnil // the key does not exist.
+	}
+
+	running.data = c.send(&req{key: requestKey, do: func(key string, now time.Time) *Item {
+		// An item saved while the Loader was running is newer than the loaded data.
+		if item := c.cache.Get(key); item != nil {
+			return c.copy(item)
+		}
+
+		c.save(&req{key: key, data: data, opts: &opts}, now, false)
+
+		return c.copy(c.cache.Get(key))
+	}})
+	if running.data == nil {
+		running.err = ErrStopped
+	}
+
+	return running.data, running.err
+}
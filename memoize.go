@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// call is an in-flight Memoize function call that other callers wait for.
+type call[T any] struct {
+	wait sync.WaitGroup
+	data T
+	err  error
+}
+
+// Memoize wraps a slow function with the cache. The returned function returns cached data
+// for a key if it was saved less than ttl ago, otherwise it calls fn and caches the result.
+// Concurrent calls for the same key are coalesced, so fn runs only once per key at a time.
+// Errors returned by fn are not cached. Pass a ttl of 0 to cache results until they're pruned.
+// If fn panics, the panic is passed up to its caller, and callers waiting for the same key
+// get an ErrMemoizePanic error.
+// Use a Namespace if the keys passed to the memoized function may collide with other keys.
+func Memoize[T any](cache *Cache, ttl time.Duration, fn func(key string) (T, error)) func(key string) (T, error) {
+	var (
+		mu    sync.Mutex
+		calls = make(map[string]*call[T])
+	)
+
+	return func(key string) (T, error) {
+		if item := cache.Get(key); item != nil && (ttl == 0 || cache.conf.Clock.Now().Sub(item.Time) < ttl) {
+			if data, ok := item.Data.(T); ok {
+				return data, nil
+			}
+		}
+
+		mu.Lock()
+		if running, ok := calls[key]; ok {
+			mu.Unlock()
+			running.wait.Wait()
+
+			return running.data, running.err
+		}
+
+		running := &call[T]{}
+		running.wait.Add(1)
+		calls[key] = running
+		mu.Unlock()
+
+		// Release the waiting callers even if fn panics.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				running.err = fmt.Errorf("%w: %v", ErrMemoizePanic, recovered)
+				defer panic(recovered)
+			}
+
+			mu.Lock()
+			delete(calls, key)
+			mu.Unlock()
+			running.wait.Done()
+		}()
+
+		running.data, running.err = fn(key)
+		if running.err == nil {
+			opts := Options{}
+			if ttl > 0 {
+				opts.Expire = cache.conf.Clock.Now().Add(ttl)
+			}
+
+			cache.Save(key, running.data, opts)
+		}
+
+		return running.data, running.err
+	}
+}
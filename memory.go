@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"log/slog"
+	"math"
+	"reflect"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+)
+
+// Memory pressure eviction settings.
+const (
+	// pressureEvictMin is the fraction of items evicted when the heap just reaches MemoryPressure.
+	pressureEvictMin = 0.1
+	// pressureEvictMax is the fraction of items evicted when the heap reaches the memory limit.
+	pressureEvictMax = 0.5
+)
+
+// itemOverhead is an estimate of the memory used by an item and its map entry, without the key or data.
+const itemOverhead = 160
+
+// Runtime metrics read to find memory pressure.
+const (
+	metricHeap  = "/memory/classes/heap/objects:bytes"
+	metricLimit = "/gc/gomemlimit:bytes"
+)
+
+// memoryPressure returns the heap in use divided by the memory limit.
+// This returns 0 if no memory limit is set.
+func memoryPressure() float64 {
+	samples := []metrics.Sample{{Name: metricHeap}, {Name: metricLimit}}
+	metrics.Read(samples)
+
+	for _, sample := range samples {
+		if sample.Value.Kind() != metrics.KindUint64 {
+			return 0 // metric not supported by this Go version.
+		}
+	}
+
+	heap, limit := samples[0].Value.Uint64(), samples[1].Value.Uint64()
+	if limit == 0 || limit == math.MaxInt64 { // no limit.
+		return 0
+	}
+
+	return float64(heap) / float64(limit)
+}
+
+// relieve evicts the least recently used items if the heap is over MemoryPressure.
+// The more memory in use, the more items are evicted, from 10% of the cache when
+// the heap reaches MemoryPressure up to half of it at the memory limit.
+// This runs inside the processor when the pruner runs.
+func (c *Cache) relieve(now time.Time) {
+	if c.conf.MemoryPressure == 0 {
+		return
+	}
+
+	pressure := memoryPressure()
+	if pressure < c.conf.MemoryPressure {
+		return
+	}
+
+	over := (pressure - c.conf.MemoryPressure) / (1 - c.conf.MemoryPressure)
+	fraction := min(pressureEvictMin+over*(pressureEvictMax-pressureEvictMin), pressureEvictMax)
+	evict := int(math.Ceil(fraction * float64(c.cache.Len())))
+
+	c.log(slog.LevelWarn, "evicting items for memory pressure",
+		"pressure", pressure, "evict", evict, "size", c.cache.Len())
+	c.evict(evict, now)
+}
+
+// evict removes n items that are not pinned: the least recently used, or the items chosen
+// by the TinyLFU admission filter or the EvictionPolicy. This runs inside the processor.
+func (c *Cache) evict(n int, now time.Time) {
+	next := c.victims(n, now)
+
+	for ; n > 0; n-- {
+		key, ok := next()
+		if !ok {
+			return
+		}
+
+		c.remove(key, evicted, now)
+	}
+}
+
+// evictFirst orders items by the least recently used first. Items used at
+// the same time are ordered by lowest priority first.
+func (c *Cache) evictFirst(a, b *KeyStat) bool {
+	if !a.Last.Equal(b.Last) {
+		return a.Last.Before(b.Last)
+	}
+
+	if left, right := c.cache.Get(a.Key).opts.Priority, c.cache.Get(b.Key).opts.Priority; left != right {
+		return left < right
+	}
+
+	return a.Key < b.Key
+}
+
+// pinned returns true for items that are never evicted.
+func pinned(_ string, item *Item) bool {
+	return item.opts.Pin
+}
+
+// capMemory evicts the least recently used items if the estimated size of the cache
+// is over MemoryFraction of the memory limit. This runs inside the processor when the pruner runs.
+func (c *Cache) capMemory(now time.Time) {
+	if c.conf.MemoryFraction == 0 || c.cache.Len() == 0 {
+		return
+	}
+
+	limit := debug.SetMemoryLimit(-1) // -1 reads the limit without changing it.
+	if limit == math.MaxInt64 {
+		return // no limit.
+	}
+
+	budget := int64(c.conf.MemoryFraction * float64(limit))
+	size := int64(0)
+
+	c.cache.Iterate(func(key string, item *Item) bool {
+		size += estimateSize(key, item)
+
+		return true
+	})
+
+	if size <= budget {
+		return
+	}
+
+	average := size / int64(c.cache.Len())
+	evict := int((size - budget + average - 1) / average) // round up.
+
+	c.log(slog.LevelWarn, "evicting items over memory budget",
+		"estimate", size, "budget", budget, "evict", evict, "size", c.cache.Len())
+	c.evict(evict, now)
+}
+
+// estimateSize returns an estimate of the memory used by a cached item. Strings and byte
+// slices are counted by length; other data is counted by the size of its type, so memory
+// referenced by pointers, maps and slices inside the data is not included.
+func estimateSize(key string, item *Item) int64 {
+	size := int64(itemOverhead + len(key))
+
+	switch data := item.Data.(type) {
+	case nil:
+	case string:
+		size += int64(len(data))
+	case []byte:
+		size += int64(cap(data))
+	default:
+		size += int64(reflect.TypeOf(data).Size())
+	}
+
+	return size
+}
+
+// capCost evicts the least recently used items until the total cost of the items is not
+// over MaxCost. This runs inside the processor when the pruner runs.
+func (c *Cache) capCost(now time.Time) {
+	if c.conf.MaxCost == 0 {
+		return
+	}
+
+	total := int64(0)
+	c.cache.Iterate(func(_ string, item *Item) bool {
+		total += item.opts.cost()
+
+		return true
+	})
+
+	if total <= c.conf.MaxCost {
+		return
+	}
+
+	c.log(slog.LevelWarn, "evicting items over max cost", "cost", total, "max", c.conf.MaxCost, "size", c.cache.Len())
+
+	for next := c.victims(c.cache.Len(), now); total > c.conf.MaxCost; {
+		key, ok := next()
+		if !ok {
+			return
+		}
+
+		total -= c.cache.Get(key).opts.cost()
+		c.remove(key, evicted, now)
+	}
+}
+
+// cost returns the item's Cost, or 1 if it does not have one.
+func (o *Options) cost() int64 {
+	if o.Cost <= 0 {
+		return 1
+	}
+
+	return o.Cost
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"hash/maphash"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// MissFilterConfig enables a Bloom filter of keys the Loader recently did not find.
+// Get, GetData and Load check the filter before they send a request to the processor,
+// and return nil for keys in it, so repeated lookups for keys that do not exist reach
+// neither the processor nor the Loader. Rejected lookups are not counted in stats.
+//
+// A key is added when the Loader returns nil data for it, and removed when it's saved.
+// Like any Bloom filter, it has false positives: a key that was never added may be in
+// the filter, and a key created in the origin is not found until the filter is reset.
+// Removing a key may remove other keys, which only costs them another Loader call.
+type MissFilterConfig struct {
+	// Keys is the number of missing keys the filter is sized for. @default 100000
+	Keys int
+	// FalsePositives is the rate of keys wrongly found in a filter with Keys keys.
+	// @default 0.01
+	FalsePositives float64
+	// Reset clears the filter this often, checked every RequestAccuracy, so keys that
+	// were created in the origin are found. @default 1 minute
+	Reset time.Duration
+}
+
+// Miss filter defaults.
+const (
+	defaultFilterKeys  = 100000
+	defaultFilterRate  = 0.01
+	defaultFilterReset = time.Minute
+)
+
+// missFilter is a Bloom filter of missing keys. has is safe for concurrent use;
+// add, remove and expire run inside the processor.
+type missFilter struct {
+	seed   maphash.Seed
+	bits   []atomic.Uint64
+	hashes uint64
+	every  time.Duration
+	reset  time.Time
+}
+
+func newMissFilter(conf *MissFilterConfig, now time.Time) *missFilter {
+	// The optimal size and number of hashes for the expected keys and false positive rate.
+	size := math.Ceil(-float64(conf.Keys) * math.Log(conf.FalsePositives) / (math.Ln2 * math.Ln2))
+	hashes := max(1, math.Round(size/float64(conf.Keys)*math.Ln2))
+
+	return &missFilter{
+		seed:   maphash.MakeSeed(),
+		bits:   make([]atomic.Uint64, int(size)/64+1), //nolint:mnd // bits in a uint64.
+		hashes: uint64(hashes),
+		every:  conf.Reset,
+		reset:  now,
+	}
+}
+
+// positions calls fn with each bit position for a key, until fn returns false.
+func (f *missFilter) positions(key string, fn func(word int, bit uint64) bool) {
+	hash := maphash.String(f.seed, key)
+	first, second := hash&math.MaxUint32, hash>>32 //nolint:mnd // splitting the hash in two.
+	size := uint64(len(f.bits)) * 64               //nolint:mnd // bits in a uint64.
+
+	for idx := uint64(0); idx < f.hashes; idx++ {
+		pos := (first + idx*second) % size
+		if !fn(int(pos/64), 1<<(pos%64)) { //nolint:mnd // bits in a uint64.
+			return
+		}
+	}
+}
+
+// has returns true if a key may be in the filter. A nil filter has no keys.
+func (f *missFilter) has(key string) bool {
+	if f == nil {
+		return false
+	}
+
+	found := true
+
+	f.positions(key, func(word int, bit uint64) bool {
+		found = f.bits[word].Load()&bit != 0
+		return found
+	})
+
+	return found
+}
+
+// add puts a key in the filter.
+func (f *missFilter) add(key string) {
+	f.positions(key, func(word int, bit uint64) bool {
+		f.bits[word].Store(f.bits[word].Load() | bit) // only the processor writes.
+		return true
+	})
+}
+
+// remove takes a key out of the filter by clearing its first bit. Keys that share the bit
+// are removed too; that's a false negative, which only costs them a Loader call.
+func (f *missFilter) remove(key string) {
+	if !f.has(key) {
+		return
+	}
+
+	f.positions(key, func(word int, bit uint64) bool {
+		f.bits[word].Store(f.bits[word].Load() &^ bit)
+		return false
+	})
+}
+
+// expire clears the filter if it's time to reset it.
+func (f *missFilter) expire(now time.Time) {
+	if f == nil || now.Sub(f.reset) < f.every {
+		return
+	}
+
+	f.reset = now
+
+	for idx := range f.bits {
+		f.bits[idx].Store(0)
+	}
+}
+
+// filterMiss adds a key the Loader did not find to the MissFilter, unless it was saved
+// while the Loader was running.
+func (c *Cache) filterMiss(requestKey string) {
+	if c.missFilter == nil {
+		return
+	}
+
+	c.send(&req{key: requestKey, do: func(key string, _ time.Time) *Item {
+		if c.cache.Get(key) == nil {
+			c.missFilter.add(key)
+		}
+
+		return nil
+	}})
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Overflow is a second tier for items evicted by MaxCost, MemoryFraction and MemoryPressure.
+// Set Config.Overflow to use one, like the cachebolt package. Evicted items are stored in it
+// instead of being lost, and a request for a key that was stored moves the item back into
+// the cache, so Get finds it as if it was never evicted. Keys include the namespace prefix.
+// The methods are called inside the cache processor, so they do not need to be safe for
+// concurrent use, and every call blocks other requests; they must not call the cache.
+//   - Store saves an evicted item, replacing any item stored with the same key.
+//   - Load returns the item stored with a key, or nil if there is none.
+//   - Delete removes the item stored with a key, after it's loaded back into the cache.
+//
+// The cache only loads keys it stored since it was created, so items left in the store by
+// a previous process are never used. Stored items are not pruned, and not included in
+// List, Export, snapshots or the journal, until they're loaded back into the cache.
+type Overflow interface {
+	Store(entry *Entry) error
+	Load(key string) (*Entry, error)
+	Delete(key string) error
+}
+
+// spill stores an evicted item in the Overflow. This runs inside the processor.
+func (c *Cache) spill(key string, item *Item) {
+	if c.conf.Overflow == nil {
+		return
+	}
+
+	entry := c.entry("", key, item)
+	if err := c.conf.Overflow.Store(&entry); err != nil {
+		c.log(slog.LevelError, "cache overflow store failed", "key", key, "error", err)
+		return
+	}
+
+	if c.spilled == nil {
+		c.spilled = make(map[string]struct{})
+	}
+
+	c.spilled[key] = struct{}{}
+}
+
+// unspill moves an item from the Overflow back into the cache, if it was stored there.
+// Items that expired while they were stored are dropped. Loaded items are not counted
+// as saves and do not send events, because they were never removed from the caller's
+// point of view. This runs inside the processor.
+func (c *Cache) unspill(key string, now time.Time) {
+	if _, ok := c.spilled[key]; !ok {
+		return
+	}
+
+	delete(c.spilled, key)
+
+	entry, err := c.conf.Overflow.Load(key)
+	if err != nil {
+		c.log(slog.LevelError, "cache overflow load failed", "key", key, "error", err)
+	}
+
+	if delErr := c.conf.Overflow.Delete(key); delErr != nil {
+		c.log(slog.LevelError, "cache overflow delete failed", "key", key, "error", delErr)
+	}
+
+	if entry == nil || (!entry.Options.Expire.IsZero() && now.After(entry.Options.Expire)) {
+		return
+	}
+
+	item := c.load(key, entry)
+	c.expireLater(key, item)
+	c.reindex(key, item)
+	c.journalItem(key, item, now)
+
+	if c.conf.Eviction != nil && !item.opts.Pin {
+		c.conf.Eviction.OnSave(key)
+	}
+}
+
+// drop deletes the items stored in the Overflow with a prefix, without loading them back
+// into the cache. Flush uses this, so flushed keys can not come back from the Overflow.
+// This runs inside the processor.
+func (c *Cache) drop(prefix string) {
+	for key := range c.spilled {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		delete(c.spilled, key)
+
+		if err := c.conf.Overflow.Delete(key); err != nil {
+			c.log(slog.LevelError, "cache overflow delete failed", "key", key, "error", err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package cache
+
+import (
+	"container/heap"
+	"container/list"
+	"time"
+)
+
+// EvictionPolicy chooses the items evicted by MaxCost, MemoryFraction and MemoryPressure.
+// Set Config.Eviction to use one instead of the default least recently used order.
+// The cache tells the policy about every key it stores, retrieves and removes, and asks
+// for a Victim each time it needs to evict an item. Keys include the namespace prefix.
+// The methods are called inside the cache processor, so they do not need to be safe for
+// concurrent use, and must not call the cache. Do not share a policy between caches.
+//   - OnGet is called when an existing key is retrieved.
+//   - OnSave is called when a key is saved, or its data is updated (like by Increment).
+//   - OnDelete is called when a key is removed from the cache for any reason.
+//   - Victim returns the key to evict next without removing it; OnDelete is called if
+//     it's evicted. Return false if there are no keys.
+//
+// Pinned items are never evicted, so they are deleted from the policy when they're pinned,
+// and saved to it again when they're unpinned. OnGet may be called for pinned keys.
+type EvictionPolicy interface {
+	OnGet(key string)
+	OnSave(key string)
+	OnDelete(key string)
+	Victim() (key string, ok bool)
+}
+
+// Make sure the built-in policies satisfy the interface.
+var (
+	_ EvictionPolicy = (*LRU)(nil)
+	_ EvictionPolicy = (*FIFO)(nil)
+	_ EvictionPolicy = (*LFU)(nil)
+)
+
+// LRU is an EvictionPolicy that evicts the least recently saved or retrieved key first.
+type LRU struct {
+	order *list.List               // least recently used at the front.
+	keys  map[string]*list.Element // elements in order, by key.
+}
+
+// NewLRU returns a least recently used eviction policy.
+func NewLRU() *LRU {
+	return &LRU{order: list.New(), keys: make(map[string]*list.Element)}
+}
+
+// OnGet moves a key to the back of the eviction order.
+func (l *LRU) OnGet(key string) {
+	if elem := l.keys[key]; elem != nil {
+		l.order.MoveToBack(elem)
+	}
+}
+
+// OnSave adds a key to the back of the eviction order, or moves it there.
+func (l *LRU) OnSave(key string) {
+	if elem := l.keys[key]; elem != nil {
+		l.order.MoveToBack(elem)
+	} else {
+		l.keys[key] = l.order.PushBack(key)
+	}
+}
+
+// OnDelete removes a key from the eviction order.
+func (l *LRU) OnDelete(key string) {
+	if elem := l.keys[key]; elem != nil {
+		l.order.Remove(elem)
+		delete(l.keys, key)
+	}
+}
+
+// Victim returns the least recently used key.
+func (l *LRU) Victim() (string, bool) {
+	if elem := l.order.Front(); elem != nil {
+		return elem.Value.(string), true //nolint:forcetypeassert
+	}
+
+	return "", false
+}
+
+// FIFO is an EvictionPolicy that evicts the first saved key first.
+// Retrieving or updating a key does not change its place.
+type FIFO struct {
+	LRU
+}
+
+// NewFIFO returns a first in, first out eviction policy.
+func NewFIFO() *FIFO {
+	return &FIFO{LRU: *NewLRU()}
+}
+
+// OnGet does nothing; a key keeps its place until it's evicted.
+func (f *FIFO) OnGet(string) {}
+
+// OnSave adds a new key to the back of the eviction order. Updated keys keep their place.
+func (f *FIFO) OnSave(key string) {
+	if f.keys[key] == nil {
+		f.keys[key] = f.order.PushBack(key)
+	}
+}
+
+// LFU is an EvictionPolicy that evicts the least frequently retrieved key first.
+// Keys retrieved the same number of times are evicted in the order they were saved.
+// Every count is halved periodically, so keys that were popular long ago do not
+// stay in the cache forever after newer keys become popular.
+type LFU struct {
+	// DecayAfter is how many retrievals pass between halving every count.
+	// @default 0, halve the counts after 10 retrievals for every key.
+	DecayAfter int
+	keys       map[string]*lfuEntry
+	heap       lfuHeap
+	saves      uint64 // counter to order keys with the same count.
+	gets       int    // retrievals since the counts were halved.
+}
+
+// lfuDecaySamples is the number of retrievals per key between halving LFU counts.
+const lfuDecaySamples = 10
+
+// lfuEntry is a key in the LFU heap.
+type lfuEntry struct {
+	key   string
+	count uint64
+	saved uint64
+	index int
+}
+
+type lfuHeap []*lfuEntry
+
+// NewLFU returns a least frequently used eviction policy.
+func NewLFU() *LFU {
+	return &LFU{keys: make(map[string]*lfuEntry)}
+}
+
+// OnGet counts a retrieval of a key, and halves every count if it's time to.
+func (l *LFU) OnGet(key string) {
+	entry := l.keys[key]
+	if entry == nil {
+		return
+	}
+
+	entry.count++
+	heap.Fix(&l.heap, entry.index)
+
+	decay := l.DecayAfter
+	if decay <= 0 {
+		decay = lfuDecaySamples * len(l.keys)
+	}
+
+	if l.gets++; l.gets >= decay {
+		l.decay()
+	}
+}
+
+// decay halves every count. Integer halving can make different counts equal,
+// so keys with the same count go back to the order they were saved in.
+func (l *LFU) decay() {
+	for _, entry := range l.heap {
+		entry.count /= 2
+	}
+
+	heap.Init(&l.heap)
+	l.gets = 0
+}
+
+// OnSave adds a new key with a count of 0. Updated keys keep their count.
+func (l *LFU) OnSave(key string) {
+	if l.keys[key] != nil {
+		return
+	}
+
+	l.saves++
+	entry := &lfuEntry{key: key, saved: l.saves}
+	l.keys[key] = entry
+	heap.Push(&l.heap, entry)
+}
+
+// OnDelete removes a key.
+func (l *LFU) OnDelete(key string) {
+	if entry := l.keys[key]; entry != nil {
+		heap.Remove(&l.heap, entry.index)
+		delete(l.keys, key)
+	}
+}
+
+// Victim returns the least frequently used key.
+func (l *LFU) Victim() (string, bool) {
+	if len(l.heap) == 0 {
+		return "", false
+	}
+
+	return l.heap[0].key, true
+}
+
+func (h lfuHeap) Len() int { return len(h) }
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count < h[j].count
+	}
+
+	return h[i].saved < h[j].saved
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *lfuHeap) Push(x any) {
+	entry := x.(*lfuEntry) //nolint:forcetypeassert
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *lfuHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+
+	return entry
+}
+
+// victims returns a function that returns the next key to evict, from the EvictionPolicy
+// if one is configured, from a random sample if EvictionSamples is set, or from evictOrder(n).
+// This runs inside the processor.
+func (c *Cache) victims(n int, now time.Time) func() (string, bool) {
+	policy := c.conf.Eviction
+	if policy == nil && c.conf.EvictionSamples > 0 && c.sketch == nil {
+		return func() (string, bool) { return c.sample(c.conf.EvictionSamples) }
+	}
+
+	if policy == nil {
+		order := c.evictOrder(n, now)
+
+		return func() (string, bool) {
+			if len(order) == 0 {
+				return "", false
+			}
+
+			key := order[0].Key
+			order = order[1:]
+
+			return key, true
+		}
+	}
+
+	return func() (string, bool) {
+		for {
+			key, ok := policy.Victim()
+
+			switch item := c.cache.Get(key); {
+			case !ok:
+				return "", false
+			case item == nil || item.opts.Pin: // the policy missed a delete or a pin; forget the key.
+				policy.OnDelete(key)
+			default:
+				return key, true
+			}
+		}
+	}
+}
+
+// sample checks up to n items that are not pinned, starting at a random place in the
+// cache, and returns the key that should be evicted first. This runs inside the processor.
+func (c *Cache) sample(n int) (string, bool) {
+	var victim *KeyStat
+
+	c.cache.Iterate(func(key string, item *Item) bool { // MapStore iteration starts at a random item.
+		if item.opts.Pin {
+			return true
+		}
+
+		stat := &KeyStat{Key: key, Last: item.Last}
+		if victim == nil || c.evictFirst(stat, victim) {
+			victim = stat
+		}
+
+		if n--; n == 0 {
+			return false
+		}
+
+		return true
+	})
+
+	if victim == nil {
+		return "", false
+	}
+
+	return victim.Key, true
+}
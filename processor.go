@@ -2,166 +2,615 @@ package cache
 
 import (
 	"context"
+	"log/slog"
+	"maps"
+	"math/rand"
+	"reflect"
+	"runtime/debug"
+	"strings"
 	"time"
 )
 
 // req is our request (input channel data).
 type req struct {
-	key  string
-	get  bool // get request.
-	stat bool // return stats.
-	list bool // return cache.
-	data any  // input data for a save op.
-	opts *Options
+	key      string
+	ns       string // namespace prefix, already included in key.
+	get      bool   // get request.
+	ref      bool   // return the stored item, not a copy. Only its Data may be read.
+	stat     bool   // return stats.
+	list     bool   // return cache.
+	exist    bool   // only save if the key exists.
+	negative bool   // save a negative (error) item.
+	data     any    // input data for a save op.
+	opts     *Options
+	do       func(key string, now time.Time) *Item // runs inside the processor for atomic operations.
+	sent     time.Time                             // when the request was sent, if TrackLatency is true.
+	res      chan *Item                            // response channel, if QueueSize is more than 0.
 }
 
 func (c *Cache) start(ctx context.Context) {
 	if c.cache == nil {
-		c.cache = make(map[string]*Item)
+		c.cache = c.conf.Store
 	}
 
-	c.req = make(chan *req)
+	if c.cache == nil {
+		c.cache = NewMapStore(c.conf.InitialCapacity)
+	}
+
+	if now := c.conf.Clock.Now(); !c.started {
+		c.started = true
+		c.loadSnapshot(now)
+		c.openJournal(now, true)
+		c.loaded()
+	} else {
+		c.openJournal(now, false)
+	}
+
+	if c.conf.Refresher != nil {
+		c.cache.Iterate(func(_ string, item *Item) bool {
+			item.refreshing = false // results from before a restart are discarded.
+
+			return true
+		})
+	}
+
+	c.req = make(chan *req, max(c.conf.QueueSize, 0))
 	c.res = make(chan *Item)
+	c.stopped = make(chan struct{})
+	c.quit = make(chan struct{})
 	c.run = true
 
+	c.log(slog.LevelInfo, "cache started", "size", c.cache.Len())
+
 	go c.processRequests(ctx)
 }
 
+// stop tells the processor to finish the requests that were already sent, and waits for it to stop.
+// The request channel is never closed, so requests sent after this return nil instead of panicking.
+// This must be called with the mutex locked.
 func (c *Cache) stop() {
-	close(c.req)
-	<-c.res // wait for it to close.
+	select {
+	case <-c.quit: // already stopping, after a Shutdown() timed out.
+	default:
+		close(c.quit)
+	}
+
+	<-c.stopped // wait for it to close.
 }
 
 // clean it up and free some memory.
 func (c *Cache) clean() {
-	for k := range c.cache {
-		c.cache[k].opts = nil
-		c.cache[k].Data = nil
-		c.cache[k] = nil
-		delete(c.cache, k)
-	}
+	c.cache.Iterate(func(k string, item *Item) bool {
+		if c.conf.Eviction != nil {
+			c.conf.Eviction.OnDelete(k)
+		}
+
+		item.opts = nil
+		item.Data = nil
+		c.cache.Delete(k)
 
-	c.cache = nil
+		return true
+	})
+
+	c.cache = nil // a new MapStore frees the memory; a custom Store is empty and reused.
+	c.spilled = nil
+	c.indexes = make(map[string]map[string]map[string]struct{})
+	c.indexed = make(map[string]map[string]string)
+	c.pruneNext = nil
+	c.expiry = nil
+}
+
+// tickers are the optional timers used by the processor. Tickers for disabled
+// features are empty and never fire.
+type tickers struct {
+	timer  Ticker // updates `now`.
+	pruner Ticker
+	statsd Ticker
+	stats  Ticker // calls OnStats.
+	snap   Ticker // writes a snapshot.
 }
 
 // processRequests readies and starts the main go routine for the cache.
 func (c *Cache) processRequests(ctx context.Context) {
-	pruner := &time.Ticker{}
+	ticks := &tickers{
+		timer:  c.conf.Clock.NewTicker(c.conf.RequestAccuracy),
+		pruner: noTicker{},
+		statsd: c.statsdTicker(),
+		stats:  noTicker{},
+		snap:   noTicker{},
+	}
+
 	if c.conf.PruneInterval > 0 {
-		pruner = time.NewTicker(c.conf.PruneInterval)
+		ticks.pruner = c.conf.Clock.NewTicker(c.pruneInterval())
 	}
 
-	timer := time.NewTicker(c.conf.RequestAccuracy)
+	if c.conf.StatsInterval > 0 && c.conf.OnStats != nil {
+		ticks.stats = c.conf.Clock.NewTicker(c.conf.StatsInterval)
+	}
+
+	if c.conf.SnapshotInterval > 0 {
+		ticks.snap = c.conf.Clock.NewTicker(c.conf.SnapshotInterval)
+	}
+
+	c.ticks = ticks
+	writer := c.startWriter()
 
 	defer func() {
-		timer.Stop()
-		pruner.Stop()
-		close(c.res) // close response channel when request channel closes.
-		c.run = false
+		ticks.stop()
+		c.stopWriter(writer) // finish the queued writes.
+
+		if c.statsd != nil {
+			c.sendStatsd() // send the final changes.
+			c.statsd.Close()
+			c.statsd = nil
+		}
+
+		c.unwatch(true) // cache is stopping, so it can't send updates anymore.
+		c.closeJournal(c.conf.Clock.Now())
+		c.takeSnapshot(true) // the final snapshot, after the last request.
+		c.log(slog.LevelInfo, "cache stopped", "size", c.cache.Len())
+		c.run = false    // before closing stopped, so Stop() returns after this write.
+		close(c.res)     // requests waiting for a response get nil.
+		close(c.stopped) // senders and refreshes stop waiting for the processor.
 	}()
 
 	// This only returns when Stop() is called or the context is Done.
-	c.processor(ctx, time.Now(), pruner, timer)
+	c.processor(ctx, c.conf.Clock.Now(), ticks)
 }
 
 // processor is the single go routine in this module for request processing.
-func (c *Cache) processor(ctx context.Context, now time.Time, pruner, timer *time.Ticker) {
+func (c *Cache) processor(ctx context.Context, now time.Time, ticks *tickers) {
 	for {
 		select {
 		case <-ctx.Done():
-			close(c.req)
+			c.drain(now)
+			return
+		case <-c.quit: // Stop() or Shutdown() called. Shutting down!
+			c.drain(now)
 			return
-		case now = <-timer.C: // usually 1 second to 1 minute, max 1 hour.
+		case now = <-ticks.timer.C(): // usually 1 second to 1 minute, max 1 hour.
 			// Update `now` with a ticker to avoid slow time.Now() calls during request processing.
-		case req, ok := <-c.req:
-			if !ok {
-				return // Stop() called. Shutting down!
+			c.unwatch(false)
+			c.syncJournal(now)
+			c.missFilter.expire(now)
+		case req := <-c.req:
+			c.process(now, req)
+		case req := <-c.refreshed:
+			req.do(req.key, now)
+		case now = <-ticks.pruner.C(): // usually a few minutes (ticker).
+			start := time.Now()
+			c.prune(&now, c.conf.PruneBatch)
+			c.stats.Pruning.Duration += time.Since(start)
+
+			if c.conf.PruneJitter > 0 {
+				ticks.pruner.Reset(c.pruneInterval())
 			}
+		case <-ticks.statsd.C():
+			c.sendStatsd()
+		case <-ticks.stats.C():
+			go c.conf.OnStats(c.snapshot())
+		case <-ticks.snap.C():
+			c.takeSnapshot(false)
+		}
+	}
+}
+
+// pruneInterval returns the PruneInterval, randomized by PruneJitter.
+func (c *Cache) pruneInterval() time.Duration {
+	if c.conf.PruneJitter == 0 {
+		return c.conf.PruneInterval
+	}
+
+	jitter := (rand.Float64()*2 - 1) * c.conf.PruneJitter //nolint:gosec,mnd // -jitter to +jitter.
+
+	return max(c.conf.PruneInterval+time.Duration(jitter*float64(c.conf.PruneInterval)), minimumPruneDur)
+}
 
+// drain processes the requests that were already sent, so their callers get a response.
+func (c *Cache) drain(now time.Time) {
+	for {
+		select {
+		case req := <-c.req:
 			c.process(now, req)
-		case now = <-pruner.C: // usually a few minutes (ticker).
-			c.prune(&now)
-			c.stats.Pruning.Duration += time.Since(now)
+		default:
+			return
 		}
 	}
 }
 
+func (t *tickers) stop() {
+	t.timer.Stop()
+	t.pruner.Stop()
+	t.statsd.Stop()
+	t.stats.Stop()
+	t.snap.Stop()
+}
+
 // process a request from the processor().
 func (c *Cache) process(now time.Time, req *req) {
+	var start time.Time
+	if !req.sent.IsZero() {
+		start = time.Now()
+		c.wait.add(start.Sub(req.sent))
+	}
+
+	// Attribute the stats changed by this request to its namespace (and parent namespaces).
+	before := c.stats
+	res := c.respond(now, req)
+
+	if req.ns != "" {
+		for prefix, stats := range c.nsStats {
+			if strings.HasPrefix(req.ns, prefix) {
+				stats.add(&before, &c.stats)
+			}
+		}
+	}
+
+	if !start.IsZero() {
+		c.work.add(time.Since(start))
+	}
+
+	if req.res != nil {
+		req.res <- res
+		return
+	}
+
+	c.res <- res
+}
+
+// respond returns the response for a request.
+func (c *Cache) respond(now time.Time, req *req) *Item {
+	c.unspill(req.key, now) // for every request that reads or changes a single key.
+
 	switch {
+	case req.do != nil:
+		defer c.recoverPanic(req.key)
+		return req.do(req.key, now)
 	case req.data != nil:
-		c.res <- c.save(req, now, req.get)
+		return c.save(req, now, req.get)
+	case req.get && req.ref:
+		return c.getRef(req.key, now)
 	case req.get:
-		c.res <- c.get(req.key, now)
+		return c.get(req.key, now)
 	case req.list:
-		c.res <- c.list()
+		return c.list(req.ns)
 	case req.stat:
-		c.res <- &Item{Data: c.stats, Hits: int64(len(c.cache))}
+		return c.stat(req.ns)
 	default:
-		c.res <- c.delete(req.key)
+		return c.delete(req.key, now)
+	}
+}
+
+// recoverPanic recovers a panic in a function passed into the processor, like the ones given
+// to Txn() and Compute(), and logs it. The request returns nil, and the processor keeps running.
+func (c *Cache) recoverPanic(key string) {
+	if recovered := recover(); recovered != nil {
+		c.log(slog.LevelError, "recovered panic in cache processor",
+			"key", key, "panic", recovered, "stack", string(debug.Stack()))
+	}
+}
+
+// log a message if a Logger is configured.
+func (c *Cache) log(level slog.Level, msg string, args ...any) {
+	if c.conf.Logger != nil {
+		c.conf.Logger.Log(context.Background(), level, msg, args...)
 	}
 }
 
 // prune (optionally) runs at an interval inside tha main thread.
-func (c *Cache) prune(from *time.Time) {
+// If batch is more than 0, only that many keys are checked, continuing from the last run.
+func (c *Cache) prune(from *time.Time, batch int) {
 	c.stats.Prunes++
+	pruned, size := c.stats.Pruned, c.cache.Len()
 
-	for key, item := range c.cache {
-		if last := from.Sub(item.Last); last > c.conf.MaxUnused ||
-			(item.opts.Prune && last > c.conf.PruneAfter) ||
-			(!item.opts.Expire.IsZero() && from.After(item.opts.Expire)) {
-			c.stats.Pruned++
-			delete(c.cache, key)
+	defer func() {
+		if pruned = c.stats.Pruned - pruned; pruned*4 >= int64(size) && pruned > 0 {
+			c.log(slog.LevelInfo, "pruned a quarter or more of the cache", "pruned", pruned, "size", size)
+		} else {
+			c.log(slog.LevelDebug, "pruned cache", "pruned", pruned, "size", size)
 		}
+	}()
+
+	// Expired items are found without checking every key.
+	c.expire(from)
+	c.relieve(*from)
+	c.capMemory(*from)
+	c.capCost(*from)
+
+	if c.expiryOnly() {
+		return
+	}
+
+	if batch <= 0 {
+		c.cache.Iterate(func(key string, item *Item) bool {
+			c.pruneItem(from, key, item)
+
+			return true
+		})
+
+		return
+	}
+
+	if len(c.pruneNext) == 0 { // start a new cycle with the current keys.
+		c.pruneNext = make([]string, 0, c.cache.Len())
+		c.cache.Iterate(func(key string, _ *Item) bool {
+			c.pruneNext = append(c.pruneNext, key)
+
+			return true
+		})
+	}
+
+	batch = min(batch, len(c.pruneNext))
+	for _, key := range c.pruneNext[:batch] {
+		if item := c.cache.Get(key); item != nil { // deleted since the cycle started.
+			c.pruneItem(from, key, item)
+		}
+	}
+
+	if c.pruneNext = c.pruneNext[batch:]; len(c.pruneNext) == 0 {
+		c.pruneNext = nil // free the cycle's keys.
 	}
 }
 
-func (c *Cache) get(key string, now time.Time) *Item {
-	if item := c.cache[key]; item != nil {
-		c.stats.Hits++
-		item.Hits++
-		item.Last = now
+// pruneItem deletes an item if it should be pruned, or starts a refresh if it should be refreshed.
+func (c *Cache) pruneItem(from *time.Time, key string, item *Item) {
+	if cause := c.pruneReason(from, item); cause != keep {
+		c.remove(key, cause, *from)
+	} else if c.refreshable(*from, item) {
+		item.refreshing = true
+		go c.refresh(key, item, c.copy(item), c.stopped)
+	}
+}
+
+// pruneCause is why the pruner removes an item. It picks the stats counter and event.
+type pruneCause uint8
+
+const (
+	keep     pruneCause = iota // not pruned.
+	expired                    // the item's Expire time passed.
+	unused                     // not retrieved in MaxUnused.
+	prunable                   // saved with Prune and not retrieved in PruneAfter.
+	custom                     // removed by PruneFunc.
+	evicted                    // least recently used, removed for memory.
+	replaced                   // replaced by a save to the same key.
+)
+
+// pruneReason returns why an item should be pruned, or keep if it should not.
+func (c *Cache) pruneReason(from *time.Time, item *Item) pruneCause {
+	pruneAfter, maxUnused := c.conf.PruneAfter, c.conf.MaxUnused
+	if item.opts.PruneAfter > 0 {
+		pruneAfter = item.opts.PruneAfter
+	}
+
+	if item.opts.MaxUnused > 0 {
+		maxUnused = item.opts.MaxUnused
+	}
+
+	switch last := from.Sub(item.Last); {
+	case !item.opts.Expire.IsZero() && from.After(item.opts.Expire):
+		return expired
+	case item.opts.Pin:
+		return keep
+	case last > maxUnused:
+		return unused
+	case item.opts.prunable() && last > pruneAfter:
+		return prunable
+	default:
+		return keep
+	}
+}
+
+// prunable returns true if the item may be pruned after PruneAfter.
+func (o *Options) prunable() bool {
+	return o.Prune || o.PruneAfter > 0
+}
 
-		return item.copy()
+// event returns the event reason sent to subscribers and watchers for a pruned item.
+func (p pruneCause) event() EventReason {
+	switch p {
+	case expired:
+		return EventExpire
+	case evicted:
+		return EventEvict
+	default:
+		return EventPrune
+	}
+}
+
+// remove deletes a pruned item, counts it in stats and emits its event. This runs inside the processor.
+func (c *Cache) remove(key string, cause pruneCause, now time.Time) {
+	if cause == evicted {
+		c.spill(key, c.cache.Get(key))
 	}
 
-	c.stats.Misses++
+	c.stats.pruned(cause)
+	c.nsPruned(key, cause)
+	c.emit(cause.event(), key, c.cache.Get(key), now)
+	c.cache.Delete(key)
+}
+
+// getRef is like get, but it returns the stored item. Only its Data may be read.
+// Items past their Expire time are removed and counted as misses, even if the pruner is not running.
+func (c *Cache) getRef(key string, now time.Time) *Item {
+	c.unspill(key, now)
+
+	item := c.cache.Get(key)
+	if item != nil && !item.opts.Expire.IsZero() && now.After(item.opts.Expire) {
+		c.remove(key, expired, now)
+		item = nil
+	}
+
+	c.access(key, item != nil)
+
+	if item == nil {
+		c.stats.Misses++
+		return nil
+	}
+
+	c.stats.Hits++
+	item.Hits++
+	item.Last = now
+
+	return item
+}
+
+func (c *Cache) get(key string, now time.Time) *Item {
+	if item := c.getRef(key, now); item != nil {
+		return c.copy(item)
+	}
 
 	return nil
 }
 
 func (c *Cache) save(req *req, now time.Time, replace bool) *Item {
+	c.unspill(req.key, now) // the saved item replaces the stored item.
+
+	if req.exist && c.cache.Get(req.key) == nil {
+		return nil // Replace() only updates existing keys.
+	}
+
 	var item *Item
 
 	if replace {
 		item = c.get(req.key, now) // Apply stats to this Update() request.
 	} else {
-		item = c.cache[req.key] // Avoid hit/miss stats on regular Save().
+		item = c.cache.Get(req.key) // Avoid hit/miss stats on regular Save().
 	}
 
 	if item != nil {
 		c.stats.Updates++
+		c.stats.pruned(replaced)
 	} else {
 		c.stats.Saves++
 	}
 
 	// Update the item in the cache with the provided value.
-	c.cache[req.key] = &Item{Data: req.data, Time: now, Last: now, opts: req.opts}
+	c.version++
+	saved := &Item{
+		Data:     req.data,
+		Time:     now,
+		Last:     now,
+		Negative: req.negative,
+		Version:  c.version,
+		opts:     c.options(req.opts, now),
+	}
+	c.cache.Set(req.key, saved)
+	c.expireLater(req.key, saved)
+
+	if item != nil {
+		c.emit(EventUpdate, req.key, saved, now)
+	} else {
+		c.emit(EventSave, req.key, saved, now)
+	}
 
 	return item // Not a copy, but also no longer in cache.
 }
 
-func (c *Cache) list() *Item {
-	items := make(map[string]*Item)
-	for key, item := range c.cache {
-		items[key] = item.copy()
+// options returns the Options to save an item with: DefaultOptions if opts is zero,
+// with Expire set from TTL.
+func (c *Cache) options(opts *Options, now time.Time) *Options {
+	if opts == nil || reflect.ValueOf(*opts).IsZero() {
+		opts = &c.conf.DefaultOptions
+	}
+
+	if opts.TTL > 0 && opts.Expire.IsZero() {
+		expiring := *opts
+		expiring.Expire = now.Add(opts.TTL)
+
+		return &expiring
 	}
 
+	return opts
+}
+
+// nsPruned counts a pruned key in the stats for every namespace it belongs to.
+func (c *Cache) nsPruned(key string, cause pruneCause) {
+	for prefix, stats := range c.nsStats {
+		if strings.HasPrefix(key, prefix) {
+			stats.pruned(cause)
+		}
+	}
+}
+
+func (c *Cache) list(prefix string) *Item {
+	items := make(map[string]*Item)
+
+	c.cache.Iterate(func(key string, item *Item) bool {
+		if strings.HasPrefix(key, prefix) {
+			items[strings.TrimPrefix(key, prefix)] = c.copy(item)
+		}
+
+		return true
+	})
+
 	return &Item{Data: items}
 }
 
-func (c *Cache) delete(key string) *Item {
-	item := c.cache[key]
+// stat returns the stats for the cache, or for a namespace, and the item count in Hits.
+func (c *Cache) stat(prefix string) *Item {
+	if prefix == "" {
+		stats := c.stats
+		stats.Wait, stats.Work = c.wait.latency(), c.work.latency()
+
+		return &Item{Data: stats, Hits: int64(c.cache.Len())}
+	}
+
+	var stats Stats
+	if c.nsStats[prefix] != nil {
+		stats = *c.nsStats[prefix]
+	}
+
+	// The pruner runs for the whole cache, not per namespace.
+	stats.Prunes = c.stats.Prunes
+	stats.Pruning = c.stats.Pruning
+	stats.Wait, stats.Work = c.wait.latency(), c.work.latency()
+
+	return &Item{Data: stats, Hits: int64(c.count(prefix))}
+}
+
+// count returns the number of keys with a prefix.
+func (c *Cache) count(prefix string) int {
+	if prefix == "" {
+		return c.cache.Len()
+	}
+
+	count := 0
+
+	c.cache.Iterate(func(key string, _ *Item) bool {
+		if strings.HasPrefix(key, prefix) {
+			count++
+		}
+
+		return true
+	})
+
+	return count
+}
+
+// flush deletes every key with a prefix and returns the number of keys deleted.
+func (c *Cache) flush(prefix string, now time.Time) int {
+	count := 0
+
+	c.drop(prefix)
+
+	c.cache.Iterate(func(key string, item *Item) bool {
+		if strings.HasPrefix(key, prefix) {
+			c.emit(EventDelete, key, item, now)
+			item.opts = nil
+			c.cache.Delete(key)
+			count++
+		}
+
+		return true
+	})
+
+	return count
+}
+
+func (c *Cache) delete(key string, now time.Time) *Item {
+	c.unspill(key, now)
+
+	item := c.cache.Get(key)
 	if item == nil {
 		c.stats.DelMiss++
 		return nil
@@ -171,18 +620,161 @@ func (c *Cache) delete(key string) *Item {
 	// this pointer in case item is returned out of the module.
 	item.opts = nil
 	c.stats.Deletes++
-	delete(c.cache, key)
+	c.emit(EventDelete, key, item, now)
+	c.cache.Delete(key)
 
 	return item // not copied.
 }
 
+// compareAndSwap runs inside the processor and returns a non-nil item if the swap happened.
+func (c *Cache) compareAndSwap(key string, oldData, newData any, opts *Options, now time.Time) *Item {
+	item := c.cache.Get(key)
+
+	switch {
+	case oldData == nil && (item != nil || newData == nil):
+		return nil // nil data is never saved; it means delete.
+	case oldData == nil:
+		// The key does not exist and that's what the caller expected. Return a non-nil item.
+		c.save(&req{key: key, data: newData, opts: opts}, now, false)
+		return &Item{}
+	case item == nil || !equal(item.Data, oldData):
+		return nil
+	case newData == nil:
+		return c.delete(key, now)
+	default:
+		return c.save(&req{key: key, data: newData, opts: opts}, now, false)
+	}
+}
+
+// increment runs inside the processor and adds delta to an integer item.
+func (c *Cache) increment(key string, delta int64, now time.Time) (int64, error) {
+	item := c.cache.Get(key)
+	if item == nil {
+		c.save(&req{key: key, data: delta, opts: &Options{}}, now, false)
+		return delta, nil
+	}
+
+	value := reflect.ValueOf(item.Data)
+	if !value.IsValid() {
+		return 0, ErrNotInteger
+	}
+
+	result := reflect.New(value.Type()).Elem()
+
+	switch value.Kind() { //nolint:exhaustive // only integers are supported.
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		result.SetInt(value.Int() + delta)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		result.SetUint(value.Uint() + uint64(delta)) //nolint:gosec // wrapping is expected.
+	default:
+		return 0, ErrNotInteger
+	}
+
+	c.stats.Updates++
+	c.emit(EventUpdate, key, c.update(key, item, result.Interface(), now), now)
+
+	if result.CanInt() {
+		return result.Int(), nil
+	}
+
+	return int64(result.Uint()), nil //nolint:gosec // wrapping is expected.
+}
+
+// append runs inside the processor and appends suffix to a string or []byte item.
+func (c *Cache) append(key string, suffix any, now time.Time) error {
+	var add []byte
+
+	switch data := suffix.(type) {
+	case string:
+		add = []byte(data)
+	case []byte:
+		add = data
+	default:
+		return ErrNotAppendable
+	}
+
+	item := c.cache.Get(key)
+	if item == nil {
+		if _, ok := suffix.([]byte); ok {
+			suffix = append([]byte{}, add...)
+		}
+
+		c.save(&req{key: key, data: suffix, opts: &Options{}}, now, false)
+
+		return nil
+	}
+
+	var appended any
+
+	switch data := item.Data.(type) {
+	case string:
+		appended = data + string(add)
+	case []byte:
+		appended = append(append(make([]byte, 0, len(data)+len(add)), data...), add...)
+	default:
+		return ErrNotAppendable
+	}
+
+	c.stats.Updates++
+	c.emit(EventUpdate, key, c.update(key, item, appended, now), now)
+
+	return nil
+}
+
+// equal compares two values without panicking on uncomparable types.
+func equal(left, right any) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+
+	return reflect.ValueOf(left).Comparable() && left == right
+}
+
+// update replaces an item with a copy that has new data, and returns the copy.
+// Stored items are replaced instead of changed, so the data returned by GetData()
+// is never written to after it's returned.
+func (c *Cache) update(key string, item *Item, data any, now time.Time) *Item {
+	updated := *item
+	c.version++
+	updated.Data, updated.Time, updated.Version = data, now, c.version
+	updated.refreshing = false // a refresh of the old item is discarded.
+	c.cache.Set(key, &updated)
+	c.expireLater(key, &updated)
+
+	return &updated
+}
+
+// Cloner is implemented by cached data that can make a deep copy of itself.
+// With Config.DeepCopy, the cache calls Clone on data that implements it every time
+// it returns a copy of an item, so callers cannot change the cached data.
+type Cloner interface {
+	Clone() any
+}
+
+// copy an item so it can be returned to the caller, and clone its data if DeepCopy is enabled.
+func (c *Cache) copy(item *Item) *Item {
+	dupe := item.copy()
+
+	if cloner, ok := dupe.Data.(Cloner); ok && c.conf.DeepCopy {
+		dupe.Data = cloner.Clone()
+	}
+
+	return dupe
+}
+
 // copy an item so it can be returned to the caller.
 // Do not call this with a nil Item.
 func (i *Item) copy() *Item {
 	return &Item{
-		Data: i.Data,
-		Time: i.Time,
-		Last: i.Last,
-		Hits: i.Hits,
+		Data:     i.Data,
+		Time:     i.Time,
+		Last:     i.Last,
+		Hits:     i.Hits,
+		Negative: i.Negative,
+		Expires:  i.opts.Expire,
+		Prunable: i.opts.prunable(),
+		Pinned:   i.opts.Pin,
+		Meta:     maps.Clone(i.opts.Meta),
+		Version:  i.Version,
 	}
 }
@@ -0,0 +1,42 @@
+package cache
+
+import "time"
+
+// Refresher is called in a go routine to get new data for an item that's due for a refresh.
+// The key includes the namespace prefix, if any. Returning nil data or an error
+// leaves the current item in place, and it will be retried at the next prune.
+type Refresher func(key string, old *Item) (any, error)
+
+// refreshable returns true if an item has been saved longer than its RefreshAfter duration,
+// and has been retrieved within that duration too. Items not being used are left for the pruner.
+func (c *Cache) refreshable(from time.Time, item *Item) bool {
+	return c.conf.Refresher != nil && item.opts.RefreshAfter > 0 && !item.refreshing &&
+		from.Sub(item.Time) > item.opts.RefreshAfter && item.Hits > 0 &&
+		from.Sub(item.Last) < item.opts.RefreshAfter
+}
+
+// refresh runs in its own go routine and sends the refreshed data back to the processor.
+// The Refresher gets old, a copy made by the processor; item is the cached pointer, and
+// it's only used inside the processor, to check that it was not replaced while refreshing.
+func (c *Cache) refresh(key string, item, old *Item, stopped chan struct{}) {
+	data, err := c.conf.Refresher(key, old)
+
+	select {
+	case <-stopped: // the processor stopped, the result has nowhere to go.
+	case c.refreshed <- &req{key: key, do: func(key string, now time.Time) *Item {
+		item.refreshing = false
+
+		switch {
+		case c.cache.Get(key) != item:
+			// The item was saved or deleted while refreshing; keep the newer data.
+		case err != nil || data == nil:
+			c.stats.RefreshErrs++
+		default:
+			c.stats.Refreshes++
+			c.emit(EventUpdate, key, c.update(key, item, data, now), now)
+		}
+
+		return nil
+	}}:
+	}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"expvar"
+	"sync"
+)
+
+// ExpvarName is the expvar variable named caches are published under.
+// It contains the stats for each running named cache, keyed by name.
+const ExpvarName = "caches"
+
+// registry holds every named cache.
+var registry = struct { //nolint:gochecknoglobals // caches register by name.
+	sync.Mutex
+	caches  map[string]*Cache
+	publish sync.Once
+}{caches: make(map[string]*Cache)}
+
+// Get returns the cache created with a Config.Name, or nil if there isn't one.
+// Caches are removed from the registry when they're stopped with clean set to true.
+func Get(name string) *Cache {
+	registry.Lock()
+	defer registry.Unlock()
+
+	return registry.caches[name]
+}
+
+// register adds a named cache to the registry, replacing any cache with the same
+// name, and publishes the registry to expvar the first time it's called.
+func register(cache *Cache) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.caches[cache.conf.Name] = cache
+	registry.publish.Do(func() {
+		expvar.Publish(ExpvarName, expvar.Func(registryStats))
+	})
+}
+
+// unregister removes a named cache from the registry, unless another cache replaced it.
+// The cache may be a namespace of the registered cache.
+func unregister(cache *Cache) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registered := registry.caches[cache.conf.Name]; registered != nil && registered.core == cache.core {
+		delete(registry.caches, cache.conf.Name)
+	}
+}
+
+// registryStats returns the stats for every running named cache, for expvar.
+func registryStats() any {
+	registry.Lock()
+	caches := make(map[string]*Cache, len(registry.caches))
+
+	for name, cache := range registry.caches {
+		caches[name] = cache
+	}
+	registry.Unlock()
+
+	stats := make(map[string]*Stats, len(caches))
+
+	for name, cache := range caches {
+		// Hold the lock so the cache cannot be stopped while getting its stats.
+		cache.mu.Lock()
+		if cache.run {
+			stats[name] = cache.Stats()
+		}
+		cache.mu.Unlock()
+	}
+
+	return stats
+}
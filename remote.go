@@ -0,0 +1,315 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// The remote protocol: every request and response is a frame with a 4-byte big-endian
+// payload length, followed by the payload. Payloads are encoded with one gob stream in
+// each direction per connection, so types are only described in the first frame that
+// uses them. A client sends one request and reads its response before sending the next.
+
+// Remote frame sizes: the header is the payload length, and maxRemoteFrame is the largest payload.
+const (
+	remoteHeader   = 4
+	maxRemoteFrame = 256 << 20
+)
+
+// Remote request operations.
+const (
+	remoteGet byte = iota + 1
+	remoteSave
+	remoteDelete
+	remoteStats
+)
+
+// remoteRequest is a request sent by a Client.
+type remoteRequest struct {
+	Op      byte
+	Key     string
+	Data    any
+	Options Options
+}
+
+// remoteResponse is a response sent by Serve. Negative items have the error message as Data.
+type remoteResponse struct {
+	Item  *Item
+	OK    bool
+	Stats *Stats
+}
+
+// remoteConn reads and writes frames on a connection.
+type remoteConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+	out, in bytes.Buffer
+	encoder *gob.Encoder
+	decoder *gob.Decoder
+}
+
+func newRemoteConn(conn net.Conn) *remoteConn {
+	remote := &remoteConn{conn: conn, reader: bufio.NewReader(conn), writer: bufio.NewWriter(conn)}
+	remote.encoder = gob.NewEncoder(&remote.out)
+	remote.decoder = gob.NewDecoder(&remote.in)
+
+	return remote
+}
+
+// write encodes v and sends it in a frame.
+func (r *remoteConn) write(v any) error {
+	r.out.Reset()
+
+	if err := r.encoder.Encode(v); err != nil {
+		return fmt.Errorf("encoding remote cache frame: %w", err)
+	} else if r.out.Len() > maxRemoteFrame {
+		return fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, r.out.Len())
+	}
+
+	header := make([]byte, remoteHeader)
+	binary.BigEndian.PutUint32(header, uint32(r.out.Len())) //nolint:gosec // frames are smaller than maxRemoteFrame.
+
+	r.writer.Write(header)
+	r.writer.Write(r.out.Bytes())
+
+	if err := r.writer.Flush(); err != nil {
+		return fmt.Errorf("writing remote cache frame: %w", err)
+	}
+
+	return nil
+}
+
+// read receives a frame and decodes it into v.
+func (r *remoteConn) read(v any) error {
+	header := make([]byte, remoteHeader)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		return fmt.Errorf("reading remote cache frame: %w", err)
+	}
+
+	size := binary.BigEndian.Uint32(header)
+	if size > maxRemoteFrame {
+		return fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, size)
+	}
+
+	r.in.Reset()
+
+	if _, err := io.CopyN(&r.in, r.reader, int64(size)); err != nil {
+		return fmt.Errorf("reading remote cache frame: %w", err)
+	}
+
+	if err := r.decoder.Decode(v); err != nil {
+		return fmt.Errorf("decoding remote cache frame: %w", err)
+	}
+
+	return nil
+}
+
+// Serve accepts connections on the listener and serves Get, Save, Delete and Stats
+// requests from a Client to the cache, or namespace, on each connection in a go routine.
+// Use it to run the cache in a sidecar process. Cached Data is encoded with encoding/gob,
+// so gob.Register() must be called with every type of data the cache holds, in the server
+// and in the client, like with GobCodec. This returns when the listener is closed;
+// connections are served until their clients close them.
+func Serve(l net.Listener, c *Cache) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return fmt.Errorf("accepting connection: %w", err)
+		}
+
+		go c.serveRemote(conn)
+	}
+}
+
+// serveRemote serves a client connection until it's closed, or it sends a bad request.
+func (c *Cache) serveRemote(conn net.Conn) {
+	defer conn.Close()
+
+	remote := newRemoteConn(conn)
+
+	for {
+		var req remoteRequest
+		if err := remote.read(&req); err != nil {
+			return
+		}
+
+		var resp remoteResponse
+
+		switch req.Op {
+		case remoteGet:
+			if resp.Item = c.Get(req.Key); resp.Item != nil {
+				if err, ok := resp.Item.Data.(error); ok && resp.Item.Negative {
+					resp.Item.Data = err.Error()
+				}
+			}
+		case remoteSave:
+			resp.OK = c.Save(req.Key, req.Data, req.Options)
+		case remoteDelete:
+			resp.OK = c.Delete(req.Key)
+		case remoteStats:
+			resp.Stats = c.Stats()
+		default:
+			return
+		}
+
+		if err := remote.write(&resp); err != nil {
+			return
+		}
+	}
+}
+
+// Client is a cache served by Serve in another process. It has the same Get, Save, Delete
+// and Stats methods as Cache, so code that uses them can use either. Requests are sent one
+// at a time on a single connection; the client is safe for concurrent use. If a request
+// fails, the connection is closed, the request returns like the key does not exist, and
+// the next request dials a new connection. Use Err to tell a failure from a miss.
+type Client struct {
+	network string
+	address string
+	timeout time.Duration
+	mu      sync.Mutex
+	conn    *remoteConn
+	err     error
+	closed  bool
+}
+
+// Dial connects to a cache served by Serve. Timeout limits dialing and each request;
+// 0 means no limit. A failed request is not retried.
+func Dial(network, address string, timeout time.Duration) (*Client, error) {
+	client := &Client{network: network, address: address, timeout: timeout}
+	if err := client.dial(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// dial opens a new connection.
+func (c *Client) dial() error {
+	conn, err := net.DialTimeout(c.network, c.address, c.timeout)
+	if err != nil {
+		return fmt.Errorf("dialing remote cache: %w", err)
+	}
+
+	c.conn = newRemoteConn(conn)
+
+	return nil
+}
+
+// do sends a request and returns the response, or nil if it fails.
+func (c *Client) do(req *remoteRequest) *remoteResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var resp remoteResponse
+	if c.err = c.roundTrip(req, &resp); c.err != nil {
+		return nil
+	}
+
+	return &resp
+}
+
+// roundTrip writes a request and reads its response, dialing first if the last request
+// failed. The connection is closed if anything fails, because its gob streams are broken.
+func (c *Client) roundTrip(req *remoteRequest, resp *remoteResponse) error {
+	if c.closed {
+		return ErrClientClosed
+	} else if c.conn == nil {
+		if err := c.dial(); err != nil {
+			return err
+		}
+	}
+
+	if c.timeout > 0 {
+		c.conn.conn.SetDeadline(time.Now().Add(c.timeout)) //nolint:errcheck // the request fails if it does not work.
+	}
+
+	err := c.conn.write(req)
+	if err == nil {
+		err = c.conn.read(resp)
+	}
+
+	if err != nil {
+		c.conn.conn.Close()
+		c.conn = nil
+	}
+
+	return err
+}
+
+// Get returns a copy of an item from the remote cache, or nil if it does not exist or the
+// request fails. Negative items have an error made from the cached error's message as Data.
+func (c *Client) Get(key string) *Item {
+	resp := c.do(&remoteRequest{Op: remoteGet, Key: key})
+	if resp == nil || resp.Item == nil {
+		return nil
+	}
+
+	if msg, ok := resp.Item.Data.(string); ok && resp.Item.Negative {
+		resp.Item.Data = errors.New(msg) //nolint:err113 // the original error can not be sent.
+	}
+
+	return resp.Item
+}
+
+// Save saves an item in the remote cache, and returns true if the key already existed.
+// This returns false if the request fails.
+func (c *Client) Save(key string, data any, opts Options) bool {
+	resp := c.do(&remoteRequest{Op: remoteSave, Key: key, Data: data, Options: opts})
+	return resp != nil && resp.OK
+}
+
+// Delete removes an item from the remote cache, and returns true if it existed.
+// This returns false if the request fails.
+func (c *Client) Delete(key string) bool {
+	resp := c.do(&remoteRequest{Op: remoteDelete, Key: key})
+	return resp != nil && resp.OK
+}
+
+// Stats returns the remote cache statistics. This is never nil; the stats are empty if
+// the request fails.
+func (c *Client) Stats() *Stats {
+	if resp := c.do(&remoteRequest{Op: remoteStats}); resp != nil && resp.Stats != nil {
+		return resp.Stats
+	}
+
+	return &Stats{}
+}
+
+// Err returns the error from the last request, or nil if it succeeded.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// Close closes the connection. Requests fail with ErrClientClosed after this.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.conn.Close()
+	c.conn = nil
+
+	if err != nil {
+		return fmt.Errorf("closing remote cache: %w", err)
+	}
+
+	return nil
+}
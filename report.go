@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeyStat describes a cached item in a report. Values are not included.
+//   - Time is when the item was saved (or updated), and Age is how long ago that was.
+//   - Last is the time of the last cache get for this item, and Idle is how long ago that was.
+type KeyStat struct {
+	Key  string    `json:"key"`
+	Hits int64     `json:"hits"`
+	Time time.Time `json:"created"`
+	Last time.Time `json:"lastAccess"`
+	Age  Duration  `json:"age"`
+	Idle Duration  `json:"idle"`
+}
+
+// SortField is a KeyStat field to sort keys by with TopBy.
+type SortField int
+
+// TopBy sort fields.
+const (
+	// SortHits sorts keys by hits, most first.
+	SortHits SortField = iota
+	// SortTime sorts keys by the time they were saved or updated, oldest first.
+	SortTime
+	// SortLast sorts keys by the time of their last cache get, least recent first.
+	SortLast
+)
+
+// keyStats is a heap of key stats. The first item is the one that's removed
+// first when the heap is full, so it holds the n best items according to better.
+type keyStats struct {
+	stats  []KeyStat
+	better func(a, b *KeyStat) bool
+}
+
+// HotKeys returns the n most retrieved keys, sorted by hits, most first.
+// This is computed inside the processor without copying any items.
+// When called on a namespace, only keys in that namespace are included.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) HotKeys(n int) []KeyStat {
+	return c.report(n, func(a, b *KeyStat) bool {
+		if a.Hits != b.Hits {
+			return a.Hits > b.Hits
+		}
+
+		return a.Key < b.Key
+	})
+}
+
+// ColdKeys returns the n coldest keys: keys that were never retrieved come first,
+// sorted by age, oldest first, followed by the least recently used keys, sorted by idle time.
+// Use this to decide what to stop caching, and to verify prune settings.
+// This is computed inside the processor without copying any items.
+// When called on a namespace, only keys in that namespace are included.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) ColdKeys(n int) []KeyStat {
+	return c.report(n, func(a, b *KeyStat) bool {
+		switch {
+		case (a.Hits == 0) != (b.Hits == 0):
+			return a.Hits == 0
+		case a.Hits == 0 && !a.Time.Equal(b.Time):
+			return a.Time.Before(b.Time)
+		case a.Hits != 0 && !a.Last.Equal(b.Last):
+			return a.Last.Before(b.Last)
+		default:
+			return a.Key < b.Key
+		}
+	})
+}
+
+// TopBy returns the n first keys sorted by a field: the most retrieved, the oldest, or the
+// stalest keys. Ties are sorted by key. This returns an empty slice for an unknown field.
+// This is computed inside the processor without copying any items.
+// When called on a namespace, only keys in that namespace are included.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) TopBy(field SortField, n int) []KeyStat {
+	switch field {
+	case SortHits:
+		return c.HotKeys(n)
+	case SortTime:
+		return c.report(n, func(a, b *KeyStat) bool {
+			if !a.Time.Equal(b.Time) {
+				return a.Time.Before(b.Time)
+			}
+
+			return a.Key < b.Key
+		})
+	case SortLast:
+		return c.report(n, func(a, b *KeyStat) bool {
+			if !a.Last.Equal(b.Last) {
+				return a.Last.Before(b.Last)
+			}
+
+			return a.Key < b.Key
+		})
+	default:
+		return []KeyStat{}
+	}
+}
+
+// report returns the n best keys according to better, sorted best first.
+func (c *Cache) report(n int, better func(a, b *KeyStat) bool) []KeyStat {
+	if n <= 0 {
+		return []KeyStat{}
+	}
+
+	stats := &keyStats{better: better} // stays empty if the processor stopped.
+
+	c.send(&req{do: func(prefix string, now time.Time) *Item {
+		stats = c.rank(n, prefix, now, better, nil)
+		return nil
+	}})
+
+	sort.Slice(stats.stats, func(i, j int) bool { return better(&stats.stats[i], &stats.stats[j]) })
+
+	return stats.stats
+}
+
+// rank returns a heap of the n best keys with a prefix according to better.
+// Items are skipped if skip is not nil and returns true for their full key and item.
+// This runs inside the processor.
+func (c *Cache) rank(n int, prefix string, now time.Time, better func(a, b *KeyStat) bool,
+	skip func(key string, item *Item) bool,
+) *keyStats {
+	stats := &keyStats{better: better}
+
+	c.cache.Iterate(func(key string, item *Item) bool {
+		if !strings.HasPrefix(key, prefix) || (skip != nil && skip(key, item)) {
+			return true
+		}
+
+		stat := KeyStat{
+			Key:  strings.TrimPrefix(key, prefix),
+			Hits: item.Hits,
+			Time: item.Time,
+			Last: item.Last,
+			Age:  Duration{now.Sub(item.Time)},
+			Idle: Duration{now.Sub(item.Last)},
+		}
+
+		if stats.Len() < n {
+			heap.Push(stats, stat)
+		} else if better(&stat, &stats.stats[0]) {
+			stats.stats[0] = stat
+			heap.Fix(stats, 0)
+		}
+
+		return true
+	})
+
+	return stats
+}
+
+func (k *keyStats) Len() int           { return len(k.stats) }
+func (k *keyStats) Less(i, j int) bool { return k.better(&k.stats[j], &k.stats[i]) }
+func (k *keyStats) Swap(i, j int)      { k.stats[i], k.stats[j] = k.stats[j], k.stats[i] }
+func (k *keyStats) Push(x any)         { k.stats = append(k.stats, x.(KeyStat)) } //nolint:forcetypeassert
+func (k *keyStats) Pop() any {
+	last := k.stats[len(k.stats)-1]
+	k.stats = k.stats[:len(k.stats)-1]
+
+	return last
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// takeSnapshot copies the items and writes them to SnapshotPath. Periodic snapshots are
+// written in a go routine, and skipped if the previous one is still being written. The
+// final snapshot waits for the previous one, and is written before this returns.
+// This runs inside the processor.
+func (c *Cache) takeSnapshot(final bool) {
+	if c.conf.SnapshotPath == "" {
+		return
+	}
+
+	if final {
+		c.snapshots.Lock()
+		defer c.snapshots.Unlock()
+
+		c.writeSnapshot(c.entries(""))
+
+		return
+	}
+
+	if !c.snapshots.TryLock() {
+		c.log(slog.LevelWarn, "cache snapshot skipped, the previous snapshot is still being written")
+		return
+	}
+
+	entries := c.entries("")
+
+	go func() {
+		defer c.snapshots.Unlock()
+		c.writeSnapshot(entries)
+	}()
+}
+
+// writeSnapshot writes entries to a temporary file, and renames it to SnapshotPath.
+func (c *Cache) writeSnapshot(entries []Entry) {
+	start := time.Now()
+
+	if err := c.replaceSnapshot(entries); err != nil {
+		c.log(slog.LevelError, "cache snapshot failed", "error", err)
+		return
+	}
+
+	c.log(slog.LevelDebug, "cache snapshot written", "size", len(entries), "elapsed", time.Since(start))
+}
+
+// replaceSnapshot writes entries to a temporary file next to SnapshotPath, syncs it, and renames it.
+func (c *Cache) replaceSnapshot(entries []Entry) error {
+	path := c.conf.SnapshotPath
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating snapshot: %w", err)
+	}
+	defer os.Remove(file.Name()) //nolint:errcheck // it's gone after the rename.
+
+	err = encodeEntries(file, entries, c.conf.SnapshotOptions)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("replacing snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// loadSnapshot loads the items in SnapshotPath into the cache, skipping items that expired.
+// This runs in start(), before the processor starts.
+func (c *Cache) loadSnapshot(now time.Time) {
+	if c.conf.SnapshotPath == "" {
+		return
+	}
+
+	file, err := os.Open(c.conf.SnapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	} else if err != nil {
+		c.log(slog.LevelError, "cache snapshot not loaded", "error", err)
+		return
+	}
+	defer file.Close()
+
+	entries, err := decodeEntries(file, c.conf.SnapshotOptions)
+	if err != nil {
+		c.log(slog.LevelError, "cache snapshot not loaded", "error", err)
+		return
+	}
+
+	for idx := range entries {
+		if expire := entries[idx].Options.Expire; expire.IsZero() || !now.After(expire) {
+			c.load(entries[idx].Key, &entries[idx])
+		}
+	}
+
+	c.log(slog.LevelInfo, "loaded cache snapshot", "size", c.cache.Len())
+}
+
+// loaded tells the expiry heap, the indexes and the EvictionPolicy about the items loaded from a
+// snapshot or a journal. This runs in start(), before the processor starts.
+func (c *Cache) loaded() {
+	c.cache.Iterate(func(key string, item *Item) bool {
+		c.expireLater(key, item)
+		c.reindex(key, item)
+
+		if c.conf.Eviction != nil && !item.opts.Pin {
+			c.conf.Eviction.OnSave(key)
+		}
+
+		return true
+	})
+}
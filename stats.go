@@ -1,40 +1,224 @@
 package cache
 
-import "time"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Stats contains the exported cache statistics.
 type Stats struct {
-	Size    int64    // derived. Count of items in cache.
-	Gets    int64    // derived. Cache gets issued.
-	Hits    int64    // Gets for cached keys.
-	Misses  int64    // Gets for missing keys.
-	Saves   int64    // Saves for a new key.
-	Updates int64    // Saves that caused an update.
-	Deletes int64    // Delete hits.
-	DelMiss int64    // Delete misses.
-	Pruned  int64    // Total items pruned.
-	Prunes  int64    // Number of times pruner has run.
-	Pruning Duration // How much time has been spent pruning.
+	Size           int64    // derived. Count of items in cache.
+	Gets           int64    // derived. Cache gets issued.
+	Hits           int64    // Gets for cached keys.
+	Misses         int64    // Gets for missing keys.
+	Saves          int64    // Saves for a new key.
+	Updates        int64    // Saves that caused an update.
+	Deletes        int64    // Delete hits.
+	DelMiss        int64    // Delete misses.
+	Pruned         int64    // Total items pruned. The sum of the next six counters.
+	Expired        int64    // Items pruned because their Expire time passed.
+	PrunedUnused   int64    // Items pruned because they were not retrieved in MaxUnused.
+	PrunedPrunable int64    // Items saved with Prune that were not retrieved in PruneAfter.
+	PrunedFunc     int64    // Items removed by PruneFunc.
+	Evicted        int64    // Least recently used items removed for MemoryPressure or MemoryFraction.
+	Replaced       int64    // Items replaced by a save to their key. Also counted in Updates.
+	Prunes         int64    // Number of times pruner has run.
+	Pruning        Duration // How much time has been spent pruning.
+	Refreshes      int64    // Items refreshed by the Refresher.
+	RefreshErrs    int64    // Refresher calls that returned an error or nil data.
+	HitRatio       float64  // derived. Hits divided by Gets, 0 without Gets.
+	PrunedPerRun   float64  // derived. Average items pruned, not Replaced, each time the pruner ran.
+	Wait           Latency  // Time requests waited for the processor. Requires TrackLatency.
+	Work           Latency  // Time the processor spent on requests. Requires TrackLatency.
 }
 
 // Duration is used to format time duration(s) in stats output.
+// It marshals to and from a string like "1m30s" in JSON, YAML and other text formats,
+// and it can be used as a command line flag with flag.Var().
 type Duration struct {
 	time.Duration
 }
 
 // Stats returns the cache statistics.
+// When called on a namespace, the counters and Size only include the namespace's
+// items and requests; Prunes, Pruning, Wait and Work are always for the whole cache.
 // This will never be nil, and concurrent access is OK.
 func (c *Cache) Stats() *Stats {
-	c.req <- &req{stat: true}
-	ret := <-c.res
+	return statsFrom(c.send(&req{stat: true}))
+}
+
+// ResetStats sets every stats counter to zero, and returns the stats from before the reset.
+// The reset happens inside the processor, so no requests are counted twice or missed.
+// When called on a namespace, only that namespace's counters are reset. When called on
+// the parent cache, the counters for the whole cache and for every namespace are reset,
+// along with the Wait and Work latency histograms.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) ResetStats() *Stats {
+	return statsFrom(c.send(&req{do: func(prefix string, _ time.Time) *Item {
+		res := c.stat(prefix)
+
+		if prefix != "" {
+			c.nsStats[prefix] = &Stats{}
+			delete(c.deltas, prefix)
+
+			return res
+		}
+
+		c.resetStats()
+
+		return res
+	}}))
+}
+
+// resetStats sets every stats counter to zero, for the whole cache and every namespace.
+// This runs inside the processor, or while it's stopped.
+func (c *Cache) resetStats() {
+	c.stats = Stats{}
+	c.statsdSent = Stats{}
+	c.wait, c.work = histogram{}, histogram{}
+	c.deltas = make(map[string]Stats)
+
+	for prefix := range c.nsStats {
+		c.nsStats[prefix] = &Stats{}
+	}
+}
+
+// StatsDelta returns the change in the stats counters since the last call to StatsDelta(),
+// or since the cache started. Size is not a counter; it's the current size. Each namespace
+// keeps its own previous snapshot. ResetStats() also resets the previous snapshot.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) StatsDelta() *Stats {
+	return statsFrom(c.send(&req{do: func(prefix string, _ time.Time) *Item {
+		res := c.stat(prefix)
+
+		stats, _ := res.Data.(Stats)
+		res.Data = stats.Delta(c.deltas[prefix])
+		c.deltas[prefix] = stats
+
+		return res
+	}}))
+}
+
+// Delta returns the change in these stats' counters since prev, for per-interval rates.
+// If a counter is less than it was in prev, the stats were reset (or the app restarted)
+// and the current value is used. Size is not a counter, so the current size is returned.
+// The derived ratios are calculated from the changes. Wait and Work are not counters;
+// the current latency percentiles are returned.
+func (s Stats) Delta(prev Stats) Stats {
+	delta := func(current, previous int64) int64 {
+		if current < previous {
+			return current
+		}
+
+		return current - previous
+	}
+
+	pruning := s.Pruning.Duration
+	if pruning >= prev.Pruning.Duration {
+		pruning -= prev.Pruning.Duration
+	}
+
+	stats := Stats{
+		Hits:           delta(s.Hits, prev.Hits),
+		Misses:         delta(s.Misses, prev.Misses),
+		Saves:          delta(s.Saves, prev.Saves),
+		Updates:        delta(s.Updates, prev.Updates),
+		Deletes:        delta(s.Deletes, prev.Deletes),
+		DelMiss:        delta(s.DelMiss, prev.DelMiss),
+		Pruned:         delta(s.Pruned, prev.Pruned),
+		Expired:        delta(s.Expired, prev.Expired),
+		PrunedUnused:   delta(s.PrunedUnused, prev.PrunedUnused),
+		PrunedPrunable: delta(s.PrunedPrunable, prev.PrunedPrunable),
+		PrunedFunc:     delta(s.PrunedFunc, prev.PrunedFunc),
+		Evicted:        delta(s.Evicted, prev.Evicted),
+		Replaced:       delta(s.Replaced, prev.Replaced),
+		Prunes:         delta(s.Prunes, prev.Prunes),
+		Pruning:        Duration{pruning},
+		Refreshes:      delta(s.Refreshes, prev.Refreshes),
+		RefreshErrs:    delta(s.RefreshErrs, prev.RefreshErrs),
+		Wait:           s.Wait,
+		Work:           s.Work,
+	}
+	stats.derive(s.Size)
+
+	return stats
+}
+
+// String returns a compact summary of the stats for log lines, like:
+// "size=120 hits=4.5k misses=312 ratio=93.5% pruned=77".
+// Counters of a thousand or more are abbreviated with k, M or G.
+func (s Stats) String() string {
+	ratio := 0.0
+	if gets := s.Hits + s.Misses; gets > 0 {
+		ratio = float64(s.Hits) / float64(gets) * 100 //nolint:mnd // percent.
+	}
+
+	return "size=" + abbreviate(s.Size) + " hits=" + abbreviate(s.Hits) + " misses=" + abbreviate(s.Misses) +
+		" ratio=" + strconv.FormatFloat(ratio, 'f', 1, 64) + "%" + " pruned=" + abbreviate(s.Pruned)
+}
+
+// abbreviate formats a counter with one decimal and a k, M or G suffix when it's large.
+func abbreviate(count int64) string {
+	const thousand = 1000
+
+	value, suffix := float64(count), ""
+
+	for _, next := range []string{"k", "M", "G"} {
+		if value < thousand && value > -thousand {
+			break
+		}
+
+		value, suffix = value/thousand, next
+	}
+
+	if suffix == "" {
+		return strconv.FormatInt(count, 10)
+	}
+
+	return strings.TrimSuffix(strconv.FormatFloat(value, 'f', 1, 64), ".0") + suffix
+}
+
+// statsFrom returns the stats from a stat() response, with derived fields set.
+func statsFrom(ret *Item) *Stats {
+	if ret == nil {
+		return &Stats{} // the processor stopped.
+	}
 
 	stats, _ := ret.Data.(Stats)
-	stats.Gets = stats.Hits + stats.Misses
-	stats.Size = ret.Hits
+	stats.derive(ret.Hits)
 
 	return &stats
 }
 
+// derive sets the derived stats fields.
+func (s *Stats) derive(size int64) {
+	s.Size = size
+	s.Gets = s.Hits + s.Misses
+	s.HitRatio, s.PrunedPerRun = 0, 0
+
+	if s.Gets > 0 {
+		s.HitRatio = float64(s.Hits) / float64(s.Gets)
+	}
+
+	if s.Prunes > 0 {
+		s.PrunedPerRun = float64(s.Pruned-s.Replaced) / float64(s.Prunes)
+	}
+}
+
+// snapshot returns a copy of the stats for the whole cache, with derived fields set.
+// This runs inside the processor.
+func (c *Cache) snapshot() Stats {
+	stats := c.stats
+	stats.Wait, stats.Work = c.wait.latency(), c.work.latency()
+	stats.derive(int64(c.cache.Len()))
+
+	return stats
+}
+
 // ExpStats returns the stats inside of an interface{} so expvar can consume it.
 // Use it in your app like this:
 //
@@ -49,7 +233,89 @@ func (c *Cache) ExpStats() any {
 	return c.Stats()
 }
 
+// add the counter changes between before and after to these stats.
+func (s *Stats) add(before, after *Stats) {
+	s.Hits += after.Hits - before.Hits
+	s.Misses += after.Misses - before.Misses
+	s.Saves += after.Saves - before.Saves
+	s.Updates += after.Updates - before.Updates
+	s.Deletes += after.Deletes - before.Deletes
+	s.DelMiss += after.DelMiss - before.DelMiss
+	s.Pruned += after.Pruned - before.Pruned
+	s.Expired += after.Expired - before.Expired
+	s.PrunedUnused += after.PrunedUnused - before.PrunedUnused
+	s.PrunedPrunable += after.PrunedPrunable - before.PrunedPrunable
+	s.PrunedFunc += after.PrunedFunc - before.PrunedFunc
+	s.Evicted += after.Evicted - before.Evicted
+	s.Replaced += after.Replaced - before.Replaced
+}
+
+// pruned counts a pruned item in Pruned and in the counter for its cause.
+func (s *Stats) pruned(cause pruneCause) {
+	s.Pruned++
+
+	switch cause {
+	case expired:
+		s.Expired++
+	case unused:
+		s.PrunedUnused++
+	case prunable:
+		s.PrunedPrunable++
+	case custom:
+		s.PrunedFunc++
+	case evicted:
+		s.Evicted++
+	case replaced:
+		s.Replaced++
+	case keep:
+	}
+}
+
 // MarshalJSON turns a Duration into a string for json or expvar.
 func (d *Duration) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + d.String() + `"`), nil
 }
+
+// UnmarshalJSON parses a Duration from a string like "1m30s",
+// or from a number of nanoseconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(`"`)) {
+		nanos, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return fmt.Errorf("parsing duration: %w", err)
+		}
+
+		d.Duration = time.Duration(nanos)
+
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("parsing duration: %w", err)
+	}
+
+	return d.UnmarshalText([]byte(text))
+}
+
+// MarshalText turns a Duration into a string for text encoders, like yaml or toml.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a Duration from a string like "1m30s".
+func (d *Duration) UnmarshalText(text []byte) error {
+	dur, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("parsing duration: %w", err)
+	}
+
+	d.Duration = dur
+
+	return nil
+}
+
+// Set parses a Duration from a string like "1m30s". This makes Duration a flag.Value.
+func (d *Duration) Set(value string) error {
+	return d.UnmarshalText([]byte(value))
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for statsd.
+const (
+	defaultStatsdPrefix   = "cache."
+	defaultStatsdInterval = 10 * time.Second
+)
+
+// StatsdConfig enables sending cache stats to a statsd or DogStatsD server.
+// Counters are sent as the change since the last interval, and Size is sent as a gauge.
+type StatsdConfig struct {
+	// Address is the host:port of the statsd server. Metrics are sent with UDP.
+	Address string
+	// Prefix is added to every metric name, like cache.hits.
+	// @default "cache."
+	Prefix string
+	// Interval controls how often metrics are sent.
+	// @default 10 seconds
+	Interval time.Duration
+	// Tags are added to every metric in DogStatsD format, like "env:prod".
+	// Leave this empty for plain statsd servers.
+	Tags []string
+}
+
+// statsdTicker opens the statsd connection and returns a ticker for sending metrics.
+// The ticker never fires if statsd is not configured or the address can't be resolved.
+func (c *Cache) statsdTicker() Ticker {
+	if c.conf.Statsd == nil || c.conf.Statsd.Address == "" {
+		return noTicker{}
+	}
+
+	conn, err := net.Dial("udp", c.conf.Statsd.Address)
+	if err != nil {
+		c.log(slog.LevelWarn, "statsd disabled", "error", err)
+		return noTicker{}
+	}
+
+	c.statsd = conn
+	c.statsdSent = c.stats
+
+	return c.conf.Clock.NewTicker(c.conf.Statsd.Interval)
+}
+
+// sendStatsd sends the stats changes since the last call to statsd.
+// This runs inside the processor. UDP writes do not block, and errors are ignored.
+func (c *Cache) sendStatsd() {
+	var (
+		buf   strings.Builder
+		last  = c.statsdSent
+		tags  string
+		conf  = c.conf.Statsd
+		stats = c.stats
+	)
+
+	if len(conf.Tags) > 0 {
+		tags = "|#" + strings.Join(conf.Tags, ",")
+	}
+
+	metric := func(name string, value int64, kind string) {
+		buf.WriteString(conf.Prefix + name + ":" + strconv.FormatInt(value, 10) + "|" + kind + tags + "\n")
+	}
+
+	metric("size", int64(c.cache.Len()), "g")
+	metric("hits", stats.Hits-last.Hits, "c")
+	metric("misses", stats.Misses-last.Misses, "c")
+	metric("saves", stats.Saves-last.Saves, "c")
+	metric("updates", stats.Updates-last.Updates, "c")
+	metric("deletes", stats.Deletes-last.Deletes, "c")
+	metric("pruned", stats.Pruned-last.Pruned, "c")
+	metric("prunes", stats.Prunes-last.Prunes, "c")
+	metric("pruning_ms", (stats.Pruning.Duration - last.Pruning.Duration).Milliseconds(), "c")
+
+	c.statsdSent = stats
+	_, _ = c.statsd.Write([]byte(strings.TrimSuffix(buf.String(), "\n")))
+}
@@ -0,0 +1,64 @@
+package cache
+
+// Store holds the items in a cache. Set Config.Store to use one instead of the default
+// MapStore, like a MapStore wrapper that mirrors changes somewhere else.
+// The methods are called inside the cache processor, so they do not need to be safe for
+// concurrent use, and must not call the cache. Do not share a store between caches.
+// Items are *Item pointers with unexported state, and the cache compares them to find
+// items that changed, so a Store must return the same pointer it was given.
+// Keys include the namespace prefix.
+//   - Get returns the item saved with a key, or nil if there is none.
+//   - Set saves an item with a key, replacing any item saved with the key.
+//   - Delete removes a key. Deleting a missing key does nothing.
+//   - Len returns the number of keys.
+//   - Iterate calls fn with every key and item until fn returns false. The cache may
+//     Delete the key it was called with, and Set existing keys, during the iteration.
+//     Random eviction (EvictionSamples) samples the first keys iterated, so a Store
+//     should not iterate in the same order every time.
+type Store interface {
+	Get(key string) *Item
+	Set(key string, item *Item)
+	Delete(key string)
+	Len() int
+	Iterate(fn func(key string, item *Item) bool)
+}
+
+// MapStore is the default Store: a Go map. Its zero value is not usable; create one with NewMapStore.
+type MapStore map[string]*Item
+
+// Make sure MapStore satisfies the interface.
+var _ Store = MapStore(nil)
+
+// NewMapStore returns an empty MapStore with room for capacity items.
+func NewMapStore(capacity int) MapStore {
+	return make(MapStore, max(capacity, 0))
+}
+
+// Get returns the item saved with a key, or nil.
+func (m MapStore) Get(key string) *Item {
+	return m[key]
+}
+
+// Set saves an item with a key.
+func (m MapStore) Set(key string, item *Item) {
+	m[key] = item
+}
+
+// Delete removes a key.
+func (m MapStore) Delete(key string) {
+	delete(m, key)
+}
+
+// Len returns the number of keys.
+func (m MapStore) Len() int {
+	return len(m)
+}
+
+// Iterate calls fn with every key and item, in random order, until fn returns false.
+func (m MapStore) Iterate(fn func(key string, item *Item) bool) {
+	for key, item := range m {
+		if !fn(key, item) {
+			return
+		}
+	}
+}
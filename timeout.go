@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// prepare a request to send to the processor.
+// This is where namespace prefixes are added to request keys.
+func (c *Cache) prepare(request *req) {
+	request.key = c.ns + request.key
+	request.ns = c.ns
+
+	if c.conf.TrackLatency {
+		request.sent = time.Now()
+	}
+}
+
+// sendTimeout sends a request with the RequestTimeout, and logs the request if it times out.
+func (c *Cache) sendTimeout(request *req) *Item {
+	item, err := c.sendContext(context.Background(), request)
+	if err != nil {
+		c.log(slog.LevelWarn, "cache request timed out", "key", request.key, "timeout", c.conf.RequestTimeout)
+	}
+
+	return item
+}
+
+// sendContext sends a prepared request, and returns an error if ctx is done or the
+// RequestTimeout passes before the response. The request has its own response channel,
+// so the processor does not block on a caller that stopped waiting.
+func (c *Cache) sendContext(ctx context.Context, request *req) (*Item, error) {
+	if c.conf.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeoutCause(ctx, c.conf.RequestTimeout, ErrRequestTimeout)
+		defer cancel()
+	}
+
+	request.res = make(chan *Item, 1)
+
+	select {
+	case c.req <- request:
+	case <-c.stopped:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, contextError(ctx)
+	}
+
+	select {
+	case item := <-request.res:
+		return item, nil
+	case <-c.stopped:
+		return c.response(request), nil
+	case <-ctx.Done():
+		return nil, contextError(ctx)
+	}
+}
+
+// contextError returns ErrRequestTimeout if the RequestTimeout passed, or the context's error.
+func contextError(ctx context.Context) error {
+	if cause := context.Cause(ctx); errors.Is(cause, ErrRequestTimeout) {
+		return cause
+	}
+
+	return fmt.Errorf("cache request: %w", ctx.Err())
+}
+
+// GetContext is like Get, but it returns an error if ctx is done, or RequestTimeout passes,
+// before the processor responds. A request the processor already received still finishes.
+// Calling this procedure after calling Stop() or cancelling the cache's context produces a panic.
+func (c *Cache) GetContext(ctx context.Context, requestKey string) (*Item, error) {
+	request := &req{key: requestKey, get: true}
+	c.prepare(request)
+
+	return c.sendContext(ctx, request)
+}
+
+// SaveContext is like Save, but it returns an error if ctx is done, or RequestTimeout passes,
+// before the processor responds. A save the processor already received still happens.
+// Calling this procedure after calling Stop() or cancelling the cache's context produces a panic.
+func (c *Cache) SaveContext(ctx context.Context, requestKey string, data any, opts Options) (bool, error) {
+	request := &req{key: requestKey, data: data, opts: &opts}
+	c.prepare(request)
+	item, err := c.sendContext(ctx, request)
+
+	return item != nil, err
+}
+
+// DeleteContext is like Delete, but it returns an error if ctx is done, or RequestTimeout passes,
+// before the processor responds. A delete the processor already received still happens.
+// Calling this procedure after calling Stop() or cancelling the cache's context produces a panic.
+func (c *Cache) DeleteContext(ctx context.Context, requestKey string) (bool, error) {
+	request := &req{key: requestKey}
+	c.prepare(request)
+	item, err := c.sendContext(ctx, request)
+
+	return item != nil, err
+}
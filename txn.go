@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// Txn is a cache transaction. It's passed into the function provided to cache.Txn().
+// Changes made with Save and Delete are only visible to the transaction until it's committed.
+// Get sees the pending changes, so a transaction reads its own writes.
+// A Txn is only valid inside the function it's passed to; do not keep it.
+type Txn struct {
+	cache   *Cache
+	now     time.Time
+	pending map[string]*Item // a nil item is a pending delete.
+	order   []string         // keeps commit order the same as call order.
+}
+
+// Txn runs fn inside the cache processor and commits all of its changes atomically.
+// When called on a namespace, the transaction's keys are inside the namespace.
+// If fn returns an error, the changes are discarded and the error is returned.
+// No other cache request is processed while fn runs, so keep it short. Calling any
+// method on the cache (not the Txn) from inside fn causes a deadlock.
+// If fn panics, the panic is recovered and logged, the changes are discarded, and an
+// error wrapping ErrTxnPanic is returned.
+// Calling this procedure after calling Stop() or cancelling the context produces a panic.
+func (c *Cache) Txn(fn func(tx *Txn) error) error {
+	var err error
+
+	c.send(&req{do: func(_ string, now time.Time) *Item {
+		txn := &Txn{cache: c, now: now, pending: make(map[string]*Item)}
+		if err = txn.run(fn); err == nil {
+			txn.commit()
+		}
+
+		return nil
+	}})
+
+	return err
+}
+
+// run calls the transaction function, and returns an error wrapping ErrTxnPanic if it panics.
+func (t *Txn) run(fn func(tx *Txn) error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			t.cache.log(slog.LevelError, "recovered panic in cache transaction",
+				"panic", recovered, "stack", string(debug.Stack()))
+			err = fmt.Errorf("%w: %v", ErrTxnPanic, recovered)
+		}
+	}()
+
+	return fn(t)
+}
+
+// Get returns a copy of an item, or nil if it doesn't exist.
+// Pending changes in this transaction are returned before cached items.
+// This updates hit/miss stats for items that are not pending, like cache.Get() does.
+func (t *Txn) Get(requestKey string) *Item {
+	requestKey = t.cache.ns + requestKey
+
+	if item, ok := t.pending[requestKey]; ok {
+		if item == nil {
+			return nil
+		}
+
+		return t.cache.copy(item)
+	}
+
+	return t.cache.get(requestKey, t.now)
+}
+
+// Save adds a pending save to the transaction, and returns true if the key already exists.
+// Like cache.Save(), saving nil data deletes the key.
+func (t *Txn) Save(requestKey string, data any, opts Options) bool {
+	requestKey = t.cache.ns + requestKey
+	exists := t.exists(requestKey)
+
+	if data == nil {
+		t.add(requestKey, nil)
+	} else {
+		t.add(requestKey, &Item{Data: data, Time: t.now, Last: t.now, opts: &opts})
+	}
+
+	return exists
+}
+
+// Delete adds a pending delete to the transaction, and returns true if the key exists.
+func (t *Txn) Delete(requestKey string) bool {
+	requestKey = t.cache.ns + requestKey
+	exists := t.exists(requestKey)
+	t.add(requestKey, nil)
+
+	return exists
+}
+
+func (t *Txn) exists(key string) bool {
+	if item, ok := t.pending[key]; ok {
+		return item != nil
+	}
+
+	t.cache.unspill(key, t.now)
+
+	return t.cache.cache.Get(key) != nil
+}
+
+func (t *Txn) add(key string, item *Item) {
+	if _, ok := t.pending[key]; !ok {
+		t.order = append(t.order, key)
+	}
+
+	t.pending[key] = item
+}
+
+// commit applies the pending changes to the cache. This runs inside the processor.
+func (t *Txn) commit() {
+	for _, key := range t.order {
+		if item := t.pending[key]; item == nil {
+			t.cache.delete(key, t.now)
+		} else {
+			t.cache.save(&req{key: key, data: item.Data, opts: item.opts}, t.now, false)
+		}
+	}
+}
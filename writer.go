@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Writer is called with a copy of every item that's saved or updated in the cache, to write
+// it to durable storage, like a database. The key includes the namespace prefix, if any.
+// Items are written after they're saved in the cache; errors are logged, and do not undo
+// the save. Deleted, pruned and evicted items are not passed to the Writer.
+type Writer func(ctx context.Context, key string, item *Item) error
+
+// writeQueueSize is the number of writes that may wait for the Writer with WriteAsync.
+const writeQueueSize = 1024
+
+// write is an item waiting for the Writer.
+type write struct {
+	key  string
+	item *Item
+}
+
+// writeThrough passes a saved or updated item to the Writer, or queues it with WriteAsync.
+// This runs inside the processor.
+func (c *Cache) writeThrough(key string, item *Item) {
+	if c.conf.Writer == nil {
+		return
+	}
+
+	if c.writes != nil {
+		c.writes <- write{key: key, item: c.copy(item)} // blocks the processor if the queue is full.
+		return
+	}
+
+	c.write(write{key: key, item: c.copy(item)})
+}
+
+// write calls the Writer and logs its error.
+func (c *Cache) write(w write) {
+	if err := c.conf.Writer(context.Background(), w.key, w.item); err != nil {
+		c.log(slog.LevelError, "cache writer failed", "key", w.key, "error", err)
+	}
+}
+
+// startWriter starts the go routine that calls the Writer with WriteAsync.
+// It returns a channel that's closed when the go routine finishes the queued writes.
+func (c *Cache) startWriter() chan struct{} {
+	done := make(chan struct{})
+
+	if c.conf.Writer == nil || !c.conf.WriteAsync {
+		close(done)
+		return done
+	}
+
+	c.writes = make(chan write, writeQueueSize)
+
+	go func(writes chan write) {
+		defer close(done)
+
+		for w := range writes {
+			c.write(w)
+		}
+	}(c.writes)
+
+	return done
+}
+
+// stopWriter closes the write queue, and waits for the queued writes to finish.
+// This runs when the processor stops.
+func (c *Cache) stopWriter(done chan struct{}) {
+	if c.writes != nil {
+		close(c.writes)
+		c.writes = nil
+	}
+
+	<-done
+}